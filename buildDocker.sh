@@ -1,7 +1,5 @@
 #!/bin/sh
 
-go get ./...
-go install ./...
-cp $GOPATH/bin/monidash monidash.tmp
+CGO_ENABLED=0 go build -o monidash.tmp ./monidash
 sudo docker build -t monitrondashboard .
 rm monidash.tmp
@@ -7,10 +7,13 @@ package monitrondashboard
 import (
 	"bufio"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net"
 	"sort"
+	"sync"
+	"time"
 )
 
 type BuildUpdate struct {
@@ -18,6 +21,40 @@ type BuildUpdate struct {
 	err    error
 }
 
+// buildErrorKind classifies the errors a BuildFetcher can send so that
+// consumers can tell a lost connection from a malformed message.
+type buildErrorKind int
+
+const (
+	BuildErrorNetwork buildErrorKind = iota
+	BuildErrorParse
+	BuildErrorServer
+)
+
+// String returns the lower case name of the error kind.
+func (k buildErrorKind) String() string {
+	switch k {
+	case BuildErrorNetwork:
+		return "network"
+	case BuildErrorParse:
+		return "parse"
+	case BuildErrorServer:
+		return "server"
+	}
+	return "unknown"
+}
+
+// buildError is an error sent along with a BuildUpdate, tagged with
+// the kind of failure that caused it.
+type buildError struct {
+	kind    buildErrorKind
+	message string
+}
+
+func (e buildError) Error() string {
+	return e.message
+}
+
 // A BuildFetcher is an interface that exposes a BuildChannel which can
 // be used to receive all BuildUpdates
 type BuildFetcher interface {
@@ -31,64 +68,170 @@ type StringUntilReader interface {
 }
 
 func NewBuildFetcher(address string) BuildFetcher {
-	buildFetcher := tcpBuildFetcher{
-		address: address,
+	return NewRecordingBuildFetcher(address, nil)
+}
+
+// reconnectDelay is how long a BuildFetcher waits before reconnecting
+// when it can't reach the server or loses its connection.
+const reconnectDelay = 5 * time.Second
+
+// NewRecordingBuildFetcher creates a BuildFetcher for address that writes
+// every line it receives to recording, if it isn't nil, so that it can be
+// played back later with a ReplayBuildFetcher.
+func NewRecordingBuildFetcher(address string, recording io.Writer) BuildFetcher {
+	return newTCPBuildFetcher(address, recording, reconnectDelay, SystemClock)
+}
+
+// newTCPBuildFetcher creates and starts a tcpBuildFetcher that waits
+// reconnectDelay between connection attempts, timed by clock.
+func newTCPBuildFetcher(address string, recording io.Writer,
+	reconnectDelay time.Duration, clock Clock) *tcpBuildFetcher {
+	buildFetcher := &tcpBuildFetcher{
+		address:        address,
+		reconnectDelay: reconnectDelay,
+		clock:          clock,
+		buildChannel:   make(chan BuildUpdate),
+		done:           make(chan bool),
 	}
-	buildFetcher.buildChannel = make(chan BuildUpdate)
-	buildFetcher.fetchBuilds()
+	if recording != nil {
+		buildFetcher.recorder = &recorder{writer: recording}
+	}
+	go buildFetcher.readLoop()
 	return buildFetcher
 }
 
 // An implementation of BuildFetcher that fetches all build info over
 // a plain tcp socket.
 type tcpBuildFetcher struct {
-	address      string
-	conn         net.Conn
-	reader       StringUntilReader
-	buildChannel chan BuildUpdate
+	address        string
+	reconnectDelay time.Duration
+	clock          Clock
+	conn           net.Conn
+	reader         StringUntilReader
+	buildChannel   chan BuildUpdate
+	recorder       *recorder
+
+	// done is closed when the fetcher is closed, mutex guards conn and
+	// done so they can be closed while reading.
+	done      chan bool
+	closeOnce sync.Once
+	mutex     sync.Mutex
 }
 
-func (bf tcpBuildFetcher) BuildChannel() chan BuildUpdate {
+func (bf *tcpBuildFetcher) BuildChannel() chan BuildUpdate {
 	return bf.buildChannel
 }
 
-func (bf *tcpBuildFetcher) fetchBuilds() {
-	var err error
-	if bf.conn, err = net.Dial("tcp", bf.address); err != nil {
-		fmt.Printf("Error Connecting")
-		return
+// Close disconnects from the server and stops reconnecting, the build
+// channel is closed once the last update has been received from it.
+func (bf *tcpBuildFetcher) Close() error {
+	bf.mutex.Lock()
+	defer bf.mutex.Unlock()
+	bf.closeOnce.Do(func() {
+		close(bf.done)
+	})
+	if bf.conn != nil {
+		return bf.conn.Close()
+	}
+	return nil
+}
+
+// connect dials the server, sending an error and returning false if it
+// can't be reached.
+func (bf *tcpBuildFetcher) connect() bool {
+	conn, err := net.Dial("tcp", bf.address)
+	if err != nil {
+		bf.buildChannel <- BuildUpdate{
+			builds: []build{},
+			err:    buildError{BuildErrorNetwork, "Error Connecting"},
+		}
+		return false
+	}
+
+	bf.mutex.Lock()
+	defer bf.mutex.Unlock()
+	select {
+	case <-bf.done:
+		conn.Close()
+		return false
+	default:
 	}
-	bf.reader = bufio.NewReader(bf.conn)
-	go bf.readLoop()
+	bf.conn = conn
+	bf.reader = bufio.NewReader(conn)
+	return true
 }
 
-func (bf tcpBuildFetcher) readLoop() {
+// readLoop reads builds from the server, reconnecting whenever the
+// connection is lost until the fetcher is closed.
+func (bf *tcpBuildFetcher) readLoop() {
 	for {
-		bf.processBuilds()
+		if bf.connect() {
+			for bf.processBuilds() {
+			}
+			bf.conn.Close()
+		}
+		select {
+		case <-bf.done:
+			close(bf.buildChannel)
+			return
+		case <-bf.clock.After(bf.reconnectDelay):
+		}
 	}
 }
 
-func (bf tcpBuildFetcher) processBuilds() {
+// processBuilds reads a line from the server and sends it as a
+// BuildUpdate, returning false if the connection has been lost.
+func (bf *tcpBuildFetcher) processBuilds() bool {
 	buildStatus, err := bf.reader.ReadString('\n')
 	if err != nil {
 		bf.buildChannel <- BuildUpdate{
 			builds: []build{},
-			err:    errors.New("Network Error"),
+			err:    buildError{BuildErrorNetwork, "Network Error"},
+		}
+		return false
+	}
+	if bf.recorder != nil {
+		if err := bf.recorder.record(buildStatus, bf.clock.Now()); err != nil {
+			// Only the first error is reported, rather than one for
+			// every line, such as when the disk is full.
+			log.Printf("Error recording, the recording has stopped: %s", err)
+			bf.recorder = nil
 		}
 	}
+	bf.buildChannel <- parseBuildUpdate(buildStatus)
+	return true
+}
+
+// parseBuildUpdate parses a line of Monitron JSON into a BuildUpdate.
+func parseBuildUpdate(buildStatus string) BuildUpdate {
 	var buildCollection jsonBuildCollection
 	if err := json.Unmarshal([]byte(buildStatus), &buildCollection); err != nil {
-		bf.buildChannel <- BuildUpdate{
+		return BuildUpdate{
 			builds: []build{},
-			err:    fmt.Errorf("Cannot Parse JSON: %s", err),
+			err: buildError{BuildErrorParse,
+				fmt.Sprintf("Cannot Parse JSON: %s", err)},
 		}
-		return
 	}
-	builds := bf.processJSONBuildIntoBuildList(buildCollection)
-	bf.buildChannel <- BuildUpdate{
-		builds: builds,
+	buildUpdate := BuildUpdate{
+		builds: processJSONBuildIntoBuildList(buildCollection),
 		err:    nil,
 	}
+	if buildCollection.Error != "" {
+		buildUpdate.err = buildCollection.buildError()
+	}
+	return buildUpdate
+}
+
+// buildError returns the error in the collection. Errors from Monitron
+// itself are server errors, but a relay also sends the kind of error it
+// had, so that a relay losing its connection isn't mistaken for one.
+func (c jsonBuildCollection) buildError() buildError {
+	for _, kind := range []buildErrorKind{BuildErrorNetwork, BuildErrorParse, BuildErrorServer} {
+		if c.ErrorKind == kind.String() {
+			return buildError{kind, c.Error}
+		}
+	}
+	return buildError{BuildErrorServer, fmt.Sprintf("Server Error: %s", c.Error)}
 }
 
 // sortByName is a sort interface for a []build that sorts by build name
@@ -106,7 +249,7 @@ func (s sortByName) Less(i, j int) bool {
 	return len(s[i].name) < len(s[j].name)
 }
 
-func (bf tcpBuildFetcher) processJSONBuildIntoBuildList(buildCollection jsonBuildCollection) []build {
+func processJSONBuildIntoBuildList(buildCollection jsonBuildCollection) []build {
 	addBuildsFromSet := func(buildSet []jsonBuild, state buildState, buildList []build) []build {
 		for _, i := range buildSet {
 			buildList = append(buildList,
@@ -115,6 +258,9 @@ func (bf tcpBuildFetcher) processJSONBuildIntoBuildList(buildCollection jsonBuil
 					buildState:   state,
 					building:     i.Building,
 					acknowledger: i.Acknowledger,
+					failingSince: i.failingSinceTime(),
+					failures:     i.Failures,
+					url:          i.URL,
 				})
 		}
 		return buildList
@@ -134,6 +280,10 @@ func (bf tcpBuildFetcher) processJSONBuildIntoBuildList(buildCollection jsonBuil
 // jsonBuildCollection is a struct for parsing the Monitron build info
 // from json.
 type jsonBuildCollection struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+	// ErrorKind is only sent by a relay, never by Monitron.
+	ErrorKind    string      `json:"error_kind,omitempty"`
 	Failing      []jsonBuild `json:"failing"`
 	Acknowledged []jsonBuild `json:"acknowledged"`
 	Healthy      []jsonBuild `json:"healthy"`
@@ -144,4 +294,56 @@ type jsonBuild struct {
 	Name         string `json:"name"`
 	Building     bool   `json:"building"`
 	Acknowledger string `json:"user"`
+	URL          string `json:"url"`
+	Failures     int    `json:"number_of_failures"`
+	FailingSince int64  `json:"failing_since"`
+}
+
+// newJSONBuildCollection converts a BuildUpdate back into the Monitron
+// JSON structure, errors are sent in the error field.
+func newJSONBuildCollection(buildUpdate BuildUpdate) jsonBuildCollection {
+	buildCollection := jsonBuildCollection{
+		Type:         "builds",
+		Failing:      []jsonBuild{},
+		Acknowledged: []jsonBuild{},
+		Healthy:      []jsonBuild{},
+	}
+	if buildUpdate.err != nil {
+		buildCollection.Error = buildUpdate.err.Error()
+		if buildErr, ok := buildUpdate.err.(buildError); ok {
+			buildCollection.ErrorKind = buildErr.kind.String()
+		}
+	}
+
+	for _, build := range buildUpdate.builds {
+		jsonBuild := jsonBuild{
+			Name:         build.name,
+			Building:     build.building,
+			Acknowledger: build.acknowledger,
+			URL:          build.url,
+			Failures:     build.failures,
+		}
+		if !build.failingSince.IsZero() {
+			jsonBuild.FailingSince = build.failingSince.UnixNano() / int64(time.Millisecond)
+		}
+
+		switch build.buildState {
+		case BuildStateFailed:
+			buildCollection.Failing = append(buildCollection.Failing, jsonBuild)
+		case BuildStateAcknowledged:
+			buildCollection.Acknowledged = append(buildCollection.Acknowledged, jsonBuild)
+		case BuildStatePassed:
+			buildCollection.Healthy = append(buildCollection.Healthy, jsonBuild)
+		}
+	}
+	return buildCollection
+}
+
+// failingSinceTime converts the millisecond timestamp Monitron sends
+// into a time, returning the zero time for builds that aren't failing.
+func (b jsonBuild) failingSinceTime() time.Time {
+	if b.FailingSince <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, b.FailingSince*int64(time.Millisecond))
 }
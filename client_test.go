@@ -1,10 +1,17 @@
 package monitrondashboard
 
 import (
+	"bytes"
 	"errors"
+	"github.com/samuelrayment/monitrondashboard/fakemonitron"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"log"
+	"net"
+	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 var testData string = "{\"type\":\"builds\",\"error\":\"\",\"failing\":[{\"name\":\"Failing Build\",\"building\":false,\"user\":\"\",\"url\":\"http://localhost:8000/job/Failing%20Build/\",\"number_of_failures\":1,\"failing_since\":1425590828000}],\"acknowledged\":[],\"healthy\":[{\"name\":\"Build\",\"building\":false,\"user\":\"\",\"url\":\"http://localhost:8000/job/Test/\",\"number_of_failures\":0,\"failing_since\":0}]}"
@@ -50,6 +57,10 @@ func TestProcessBuildsCreatesAndSortsABuildSlice(t *testing.T) {
 	assert.Equal(t, "", secondBuild.acknowledger)
 	assert.Equal(t, BuildStateFailed, secondBuild.buildState)
 	assert.Equal(t, "Failing Build", secondBuild.name, "Builds should be sorted alphabetically so 'Failing Build' is second")
+	assert.Equal(t, int64(1425590828), secondBuild.failingSince.Unix())
+	assert.Equal(t, 1, secondBuild.failures)
+	assert.Equal(t, "http://localhost:8000/job/Failing%20Build/", secondBuild.url)
+	assert.True(t, firstBuild.failingSince.IsZero(), "Healthy builds should not have a failing since time")
 }
 
 func TestProcessBuildsErrorsIfItCantParseJSON(t *testing.T) {
@@ -66,6 +77,7 @@ func TestProcessBuildsErrorsIfItCantParseJSON(t *testing.T) {
 
 	buildUpdate := <-buildFetcher.buildChannel
 	assert.Error(t, buildUpdate.err, "processBuilds() should error on malformed json")
+	assert.Equal(t, "parse", errorKindName(buildUpdate.err))
 }
 
 func TestProcessBuildsErrorsOnNetworkError(t *testing.T) {
@@ -82,4 +94,170 @@ func TestProcessBuildsErrorsOnNetworkError(t *testing.T) {
 
 	buildUpdate := <-buildFetcher.buildChannel
 	assert.Error(t, buildUpdate.err, "processBuilds() should error on a network error")
+	assert.Equal(t, "network", errorKindName(buildUpdate.err))
+}
+
+func TestProcessBuildsErrorsWhenTheServerReportsAnError(t *testing.T) {
+	mockStringReader := MockStringUntilReader{}
+	buildFetcher := tcpBuildFetcher{
+		conn:         nil,
+		reader:       &mockStringReader,
+		buildChannel: make(chan BuildUpdate, 2),
+	}
+	mockStringReader.Mock.On("ReadString", '\n').Return("{\"type\":\"builds\",\"error\":\"Jenkins is down\"}", nil)
+
+	buildFetcher.processBuilds()
+
+	buildUpdate := <-buildFetcher.buildChannel
+	assert.EqualError(t, buildUpdate.err, "Server Error: Jenkins is down")
+	assert.Equal(t, "server", errorKindName(buildUpdate.err))
+}
+
+func TestBuildCollectionRoundTripsThroughJSON(t *testing.T) {
+	builds := []build{
+		{name: "Build", buildState: BuildStatePassed, building: true},
+		{name: "Failing Build", buildState: BuildStateFailed,
+			failingSince: time.Unix(1425590828, 0), failures: 2,
+			url: "http://localhost:8000/job/Failing%20Build/"},
+		{name: "Acknowledged", buildState: BuildStateAcknowledged,
+			acknowledger: "Dave"},
+	}
+
+	buildCollection := newJSONBuildCollection(BuildUpdate{builds: builds})
+	assert.Equal(t, "builds", buildCollection.Type)
+
+	roundTripped := processJSONBuildIntoBuildList(buildCollection)
+	assert.ElementsMatch(t, builds, roundTripped)
+}
+
+// startFakeServer starts a fake Monitron server for scenario on a local
+// port, returning its address.
+func startFakeServer(t *testing.T, scenario fakemonitron.Scenario) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error listening: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go fakemonitron.NewServer(scenario).Serve(listener)
+	return listener.Addr().String()
+}
+
+func TestBuildFetcherReadsSplitLinesFromAServer(t *testing.T) {
+	address := startFakeServer(t, fakemonitron.Scenario{
+		Builds:      3,
+		Interval:    time.Hour,
+		SplitWrites: true,
+	})
+
+	fetcher := newTCPBuildFetcher(address, nil, time.Millisecond, SystemClock)
+	buildUpdate := <-fetcher.BuildChannel()
+
+	assert.NoError(t, buildUpdate.err)
+	assert.Len(t, buildUpdate.builds, 3)
+}
+
+func TestBuildFetcherReportsMalformedLinesFromAServer(t *testing.T) {
+	address := startFakeServer(t, fakemonitron.Scenario{
+		Builds:        3,
+		Interval:      time.Hour,
+		MalformedRate: 1,
+	})
+
+	fetcher := newTCPBuildFetcher(address, nil, time.Millisecond, SystemClock)
+	buildUpdate := <-fetcher.BuildChannel()
+
+	assert.Equal(t, "parse", errorKindName(buildUpdate.err))
+}
+
+func TestBuildFetcherReconnectsWhenDisconnected(t *testing.T) {
+	address := startFakeServer(t, fakemonitron.Scenario{
+		Builds:         3,
+		Interval:       time.Hour,
+		DisconnectRate: 1,
+	})
+
+	fetcher := newTCPBuildFetcher(address, nil, time.Millisecond, SystemClock)
+
+	assert.NoError(t, (<-fetcher.BuildChannel()).err)
+	assert.EqualError(t, (<-fetcher.BuildChannel()).err, "Network Error")
+	buildUpdate := <-fetcher.BuildChannel()
+	assert.NoError(t, buildUpdate.err, "The fetcher should have reconnected")
+	assert.Len(t, buildUpdate.builds, 3)
+}
+
+func TestBuildFetcherRetriesWhenItCannotConnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error listening: %s", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	fetcher := newTCPBuildFetcher(address, nil, time.Millisecond, SystemClock)
+	assert.EqualError(t, (<-fetcher.BuildChannel()).err, "Error Connecting")
+
+	listener, err = net.Listen("tcp", address)
+	if err != nil {
+		t.Fatalf("Unexpected error listening again: %s", err)
+	}
+	defer listener.Close()
+	go fakemonitron.NewServer(fakemonitron.Scenario{
+		Builds:   1,
+		Interval: time.Hour,
+	}).Serve(listener)
+
+	for buildUpdate := range fetcher.BuildChannel() {
+		if buildUpdate.err == nil {
+			assert.Len(t, buildUpdate.builds, 1)
+			break
+		}
+	}
+}
+
+// fullDiskWriter is a recording that fails every write, counting them.
+type fullDiskWriter struct {
+	writes int
+}
+
+func (w *fullDiskWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("no space left on device")
+}
+
+func TestProcessBuildsLogsTheFirstRecordingErrorAndStopsRecording(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	mockStringReader := MockStringUntilReader{}
+	recording := &fullDiskWriter{}
+	buildFetcher := tcpBuildFetcher{
+		conn:         nil,
+		reader:       &mockStringReader,
+		buildChannel: make(chan BuildUpdate, 2),
+		recorder:     &recorder{writer: recording},
+		clock:        SystemClock,
+	}
+	mockStringReader.Mock.On("ReadString", '\n').Return(testData, nil)
+
+	buildFetcher.processBuilds()
+	buildFetcher.processBuilds()
+
+	assert.NoError(t, (<-buildFetcher.buildChannel).err)
+	assert.NoError(t, (<-buildFetcher.buildChannel).err, "Builds should still be sent")
+	assert.Equal(t, 1, recording.writes)
+	assert.Equal(t, 1, strings.Count(logged.String(), "no space left on device"))
+}
+
+func TestBuildFetcherClosesItsChannelWhenClosed(t *testing.T) {
+	address := startFakeServer(t, fakemonitron.Scenario{
+		Builds:   1,
+		Interval: time.Hour,
+	})
+
+	fetcher := newTCPBuildFetcher(address, nil, time.Millisecond, SystemClock)
+	assert.NoError(t, (<-fetcher.BuildChannel()).err)
+	fetcher.Close()
+
+	for range fetcher.BuildChannel() {
+	}
 }
@@ -0,0 +1,199 @@
+package monitrondashboard
+
+// Clocks for the monitron dashboard.
+// Here you'll find the Clock that the dashboard and fetchers tell the
+// time with, and a FakeClock that tests can move forward by hand.
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and times intervals.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTicker returns a Ticker sending the time every interval.
+	NewTicker(interval time.Duration) Ticker
+	// After sends the time once duration has passed.
+	After(duration time.Duration) <-chan time.Time
+	// AfterFunc calls f in its own goroutine once duration has passed,
+	// unless the returned Timer is stopped first.
+	AfterFunc(duration time.Duration, f func()) Timer
+}
+
+// Ticker sends the time at regular intervals until it is stopped.
+type Ticker interface {
+	// C returns the channel the time is sent on.
+	C() <-chan time.Time
+	Stop()
+}
+
+// Timer calls a function once, unless it is stopped first.
+type Timer interface {
+	// Stop stops the timer, returning false if it has already called its
+	// function or been stopped.
+	Stop() bool
+}
+
+// SystemClock is the Clock using the system's time.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(interval time.Duration) Ticker {
+	return systemTicker{time.NewTicker(interval)}
+}
+
+func (systemClock) After(duration time.Duration) <-chan time.Time {
+	return time.After(duration)
+}
+
+func (systemClock) AfterFunc(duration time.Duration, f func()) Timer {
+	return time.AfterFunc(duration, f)
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// FakeClock is a Clock for tests whose time only changes when it is
+// advanced.
+type FakeClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is a ticker, After or AfterFunc waiting for a FakeClock to
+// reach at, tickers are then due again interval later. The time is sent
+// on c, or f is called if it is set.
+type fakeWaiter struct {
+	clock    *FakeClock
+	at       time.Time
+	interval time.Duration
+	c        chan time.Time
+	f        func()
+}
+
+// NewFakeClock creates a FakeClock starting at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (f *FakeClock) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.now
+}
+
+// NewTicker returns a Ticker whose ticks are sent while the clock is
+// advanced, waiting for each to be received as a slow receiver would
+// otherwise miss them.
+func (f *FakeClock) NewTicker(interval time.Duration) Ticker {
+	return f.wait(interval, interval, make(chan time.Time), nil)
+}
+
+func (f *FakeClock) After(duration time.Duration) <-chan time.Time {
+	return f.wait(duration, 0, make(chan time.Time, 1), nil).c
+}
+
+// AfterFunc returns a Timer calling fn in its own goroutine when the
+// clock is advanced past duration.
+func (f *FakeClock) AfterFunc(duration time.Duration, fn func()) Timer {
+	return fakeTimer{f.wait(duration, 0, nil, fn)}
+}
+
+// wait adds a waiter due after duration.
+func (f *FakeClock) wait(duration, interval time.Duration, c chan time.Time,
+	fn func()) *fakeWaiter {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	waiter := &fakeWaiter{clock: f, at: f.now.Add(duration), interval: interval, c: c, f: fn}
+	f.waiters = append(f.waiters, waiter)
+	return waiter
+}
+
+// Advance moves the clock forward by duration, sending the time to each
+// ticker and After that becomes due on the way, in order, and starting
+// the function of each AfterFunc. It returns once every tick has been
+// received.
+func (f *FakeClock) Advance(duration time.Duration) {
+	f.mutex.Lock()
+	end := f.now.Add(duration)
+	for {
+		waiter := f.nextWaiter(end)
+		if waiter == nil {
+			break
+		}
+		f.now = waiter.at
+		now := f.now
+		if waiter.interval > 0 {
+			waiter.at = waiter.at.Add(waiter.interval)
+		} else {
+			f.remove(waiter)
+		}
+		f.mutex.Unlock()
+		if waiter.f != nil {
+			go waiter.f()
+		} else {
+			waiter.c <- now
+		}
+		f.mutex.Lock()
+	}
+	f.now = end
+	f.mutex.Unlock()
+}
+
+// nextWaiter returns the first waiter due by end, or nil if there isn't
+// one.
+func (f *FakeClock) nextWaiter(end time.Time) *fakeWaiter {
+	var next *fakeWaiter
+	for _, waiter := range f.waiters {
+		if !waiter.at.After(end) && (next == nil || waiter.at.Before(next.at)) {
+			next = waiter
+		}
+	}
+	return next
+}
+
+// remove stops waiter being sent the time, returning false if it had
+// already been removed.
+func (f *FakeClock) remove(waiter *fakeWaiter) bool {
+	for i, w := range f.waiters {
+		if w == waiter {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+func (w *fakeWaiter) Stop() {
+	w.clock.mutex.Lock()
+	defer w.clock.mutex.Unlock()
+	w.clock.remove(w)
+}
+
+// fakeTimer is the Timer of a FakeClock's AfterFunc.
+type fakeTimer struct {
+	waiter *fakeWaiter
+}
+
+func (t fakeTimer) Stop() bool {
+	t.waiter.clock.mutex.Lock()
+	defer t.waiter.clock.mutex.Unlock()
+	return t.waiter.clock.remove(t.waiter)
+}
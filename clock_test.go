@@ -0,0 +1,76 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFakeClockOnlyMovesWhenAdvanced(t *testing.T) {
+	start := time.Unix(1425590828, 0)
+	clock := NewFakeClock(start)
+
+	assert.Equal(t, start, clock.Now())
+	clock.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), clock.Now())
+}
+
+func TestFakeClockTicksAsItIsAdvanced(t *testing.T) {
+	start := time.Unix(1425590828, 0)
+	clock := NewFakeClock(start)
+	ticker := clock.NewTicker(10 * time.Second)
+	ticks := make(chan time.Time, 5)
+	go func() {
+		for tick := range ticker.C() {
+			ticks <- tick
+		}
+	}()
+
+	clock.Advance(25 * time.Second)
+	assert.Equal(t, start.Add(10*time.Second), <-ticks)
+	assert.Equal(t, start.Add(20*time.Second), <-ticks)
+	assert.Empty(t, ticks, "The next tick isn't due until 30 seconds")
+
+	ticker.Stop()
+	clock.Advance(time.Minute)
+	assert.Empty(t, ticks, "A stopped ticker shouldn't tick")
+}
+
+func TestFakeClockCallsAfterFuncsUnlessStopped(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1425590828, 0))
+	called := make(chan string, 2)
+	clock.AfterFunc(5*time.Second, func() { called <- "first" })
+	stopped := clock.AfterFunc(5*time.Second, func() { called <- "stopped" })
+
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop(), "A timer can only be stopped once")
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, "first", <-called)
+	assert.Empty(t, called)
+}
+
+// waitForWaiters waits until count tickers, Afters or AfterFuncs are
+// waiting on clock, for code that starts waiting in another goroutine.
+func waitForWaiters(t *testing.T, clock *FakeClock, count int) {
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		clock.mutex.Lock()
+		waiting := len(clock.waiters)
+		clock.mutex.Unlock()
+		if waiting >= count {
+			return
+		}
+	}
+	t.Fatalf("Nothing started waiting on the clock")
+}
+
+func TestFakeClockSendsAfterTheDuration(t *testing.T) {
+	start := time.Unix(1425590828, 0)
+	clock := NewFakeClock(start)
+	after := clock.After(5 * time.Second)
+
+	clock.Advance(4 * time.Second)
+	assert.Empty(t, after)
+	clock.Advance(4 * time.Second)
+	assert.Equal(t, start.Add(5*time.Second), <-after)
+	assert.Equal(t, start.Add(8*time.Second), clock.Now())
+}
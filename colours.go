@@ -0,0 +1,300 @@
+package monitrondashboard
+
+// Terminal colour support for the monitron dashboard.
+// Here you'll find the colours the dashboard is drawn in, code for
+// working out how many colours the terminal can show, and for turning a
+// theme into one the terminal can draw.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Colour is a colour cells are drawn in, with any attributes such as
+// bold added to it. It is the terminal's default colour, a colour from
+// the xterm 256 colour palette or an exact RGB colour, which is replaced
+// by the nearest palette colour on terminals without true colour.
+type Colour uint64
+
+// The named colours, the first 8 colours of the xterm palette.
+const (
+	ColourDefault Colour = iota
+	ColourBlack
+	ColourRed
+	ColourGreen
+	ColourYellow
+	ColourBlue
+	ColourMagenta
+	ColourCyan
+	ColourWhite
+)
+
+// The attributes that can be added to a foreground colour.
+const (
+	AttrBold Colour = 1 << (iota + 9)
+	AttrUnderline
+	AttrReverse
+)
+
+const (
+	// paletteMask covers the xterm palette index of a colour, offset by
+	// one to leave 0 as the default colour.
+	paletteMask Colour = 0x1ff
+	// attributeMask covers the attributes added to a colour.
+	attributeMask = AttrBold | AttrUnderline | AttrReverse
+	// rgbFlag marks an RGB colour, with its components from rgbShift up.
+	rgbFlag  Colour = 1 << 15
+	rgbShift        = 16
+)
+
+// xtermColour returns the colour index of the xterm 256 colour palette.
+func xtermColour(index int) Colour {
+	return Colour(index + 1)
+}
+
+// RGBColour returns the exact colour with red, green and blue components
+// r, g and b.
+func RGBColour(r, g, b uint8) Colour {
+	return rgbFlag | Colour(r)<<(rgbShift+16) | Colour(g)<<(rgbShift+8) | Colour(b)<<rgbShift
+}
+
+// isDefault returns true if c is the terminal's default colour.
+func (c Colour) isDefault() bool {
+	return c&(paletteMask|rgbFlag) == 0
+}
+
+// rgb returns the red, green and blue components of c, the default
+// colour is treated as black.
+func (c Colour) rgb() (r, g, b int) {
+	switch {
+	case c&rgbFlag != 0:
+		return int(c>>(rgbShift+16)) & 0xff, int(c>>(rgbShift+8)) & 0xff, int(c>>rgbShift) & 0xff
+	case c.isDefault():
+		return 0, 0, 0
+	}
+	return xtermRGB(int(c&paletteMask) - 1)
+}
+
+// quantise returns the colour among the first colours of the xterm
+// palette that looks most like c, keeping its attributes. The default
+// colour is left as it is.
+func (c Colour) quantise(colours int) Colour {
+	if c.isDefault() || c&rgbFlag == 0 && int(c&paletteMask)-1 < colours {
+		return c
+	}
+
+	r, g, b := c.rgb()
+	nearest, nearestDistance := 0, -1
+	for candidate := 0; candidate < colours; candidate++ {
+		cr, cg, cb := xtermRGB(candidate)
+		distance := (r-cr)*(r-cr) + (g-cg)*(g-cg) + (b-cb)*(b-cb)
+		if nearestDistance < 0 || distance < nearestDistance {
+			nearest, nearestDistance = candidate, distance
+		}
+	}
+	return c&attributeMask | xtermColour(nearest)
+}
+
+// colourNamesInOrder are the names of the named colours, indexed by
+// their value.
+var colourNamesInOrder = []string{
+	"default", "black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+}
+
+// String returns c as it is written in the config file, e.g. "white
+// bold", "166 underline" or "#d75f00".
+func (c Colour) String() string {
+	var words []string
+	switch {
+	case c&rgbFlag != 0:
+		r, g, b := c.rgb()
+		words = append(words, fmt.Sprintf("#%02x%02x%02x", r, g, b))
+	case int(c&paletteMask) < len(colourNamesInOrder):
+		words = append(words, colourNamesInOrder[c&paletteMask])
+	default:
+		words = append(words, fmt.Sprint(int(c&paletteMask)-1))
+	}
+	for _, attribute := range []struct {
+		attribute Colour
+		name      string
+	}{{AttrBold, "bold"}, {AttrUnderline, "underline"}, {AttrReverse, "reverse"}} {
+		if c&attribute.attribute != 0 {
+			words = append(words, attribute.name)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// MarshalText encodes c as it is written in the config file.
+func (c Colour) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// ColourMode is how many colours the dashboard draws with.
+type ColourMode string
+
+const (
+	// ColourModeAuto works out the colour mode from the environment.
+	ColourModeAuto ColourMode = "auto"
+	// ColourModeTrueColour draws RGB colours exactly, for terminals with
+	// 24-bit colour.
+	ColourModeTrueColour ColourMode = "truecolour"
+	// ColourMode256 uses the xterm 256 colour palette.
+	ColourMode256 ColourMode = "256"
+	// ColourMode16 uses the 8 standard colours and their bright versions.
+	ColourMode16 ColourMode = "16"
+	// ColourMode8 uses the 8 standard colours.
+	ColourMode8 ColourMode = "8"
+	// ColourModeMonochrome uses no colours at all, telling build states
+	// apart with reverse video, bold and the state glyphs.
+	ColourModeMonochrome ColourMode = "monochrome"
+)
+
+// colourModes are the known ColourModes.
+var colourModes = []string{
+	string(ColourModeAuto),
+	string(ColourModeTrueColour),
+	string(ColourMode256),
+	string(ColourMode16),
+	string(ColourMode8),
+	string(ColourModeMonochrome),
+}
+
+// ParseColourMode returns the ColourMode named mode, or an error if it
+// isn't one of the colourModes.
+func ParseColourMode(mode string) (ColourMode, error) {
+	for _, known := range colourModes {
+		if mode == known {
+			return ColourMode(mode), nil
+		}
+	}
+	return "", fmt.Errorf("unknown colour mode %q, expected one of %v", mode, colourModes)
+}
+
+// monochromeTerminals are the prefixes of TERM values for terminals
+// without colour.
+var monochromeTerminals = []string{"dumb", "vt"}
+
+// sixteenColourTerminals are the prefixes of TERM values for terminals
+// known to have bright colours, other terminals are assumed to have 8.
+var sixteenColourTerminals = []string{
+	"xterm", "rxvt", "screen", "tmux", "putty", "konsole", "gnome",
+}
+
+// DetectColourMode works out how many colours the terminal can show from
+// the NO_COLOR, COLORTERM and TERM environment variables, looked up with
+// getenv.
+func DetectColourMode(getenv func(string) string) ColourMode {
+	if getenv("NO_COLOR") != "" {
+		return ColourModeMonochrome
+	}
+	term := getenv("TERM")
+	if term == "" || strings.HasSuffix(term, "-mono") ||
+		hasAnyPrefix(term, monochromeTerminals) {
+		return ColourModeMonochrome
+	}
+	switch getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return ColourModeTrueColour
+	}
+	switch {
+	case strings.Contains(term, "256color"):
+		return ColourMode256
+	case strings.Contains(term, "16color"), hasAnyPrefix(term, sixteenColourTerminals):
+		return ColourMode16
+	}
+	return ColourMode8
+}
+
+// hasAnyPrefix returns true if s starts with any of prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// paletteSize returns how many colours of the xterm palette can be used
+// in the colour mode, or 0 if it isn't limited to the palette.
+func (m ColourMode) paletteSize() int {
+	switch m {
+	case ColourMode256:
+		return 256
+	case ColourMode16:
+		return 16
+	case ColourMode8:
+		return 8
+	}
+	return 0
+}
+
+// monochromeTheme is the theme used when the terminal has no colours.
+// Failed and acknowledged builds have reversed swatches, with failed
+// builds in bold, and every state has its own glyph.
+var monochromeTheme = Theme{
+	Failed:       Style{ColourDefault | AttrBold, ColourDefault | AttrReverse},
+	Acknowledged: Style{ColourDefault, ColourDefault | AttrReverse},
+	Passed:       Style{ColourDefault, ColourDefault},
+	Unknown:      Style{ColourDefault | AttrUnderline, ColourDefault},
+	Building:     Style{ColourDefault | AttrBold, ColourDefault},
+	Title:        Style{ColourDefault | AttrBold, ColourDefault},
+	Border:       Style{ColourDefault, ColourDefault},
+	Text:         Style{ColourDefault, ColourDefault},
+	Error:        Style{ColourDefault | AttrBold, ColourDefault},
+}
+
+// forColourMode returns the theme as it should be drawn in mode, with
+// each colour replaced by the nearest one the terminal has.
+func (t Theme) forColourMode(mode ColourMode) Theme {
+	if mode == ColourModeMonochrome {
+		return monochromeTheme
+	}
+	colours := mode.paletteSize()
+	if colours == 0 {
+		return t
+	}
+	return t.mapStyles(func(style Style) Style {
+		return Style{style.Fg.quantise(colours), style.Bg.quantise(colours)}
+	})
+}
+
+// mapStyles returns the theme with each of its styles changed by f.
+func (t Theme) mapStyles(f func(Style) Style) Theme {
+	for _, style := range []*Style{
+		&t.Failed, &t.Acknowledged, &t.Passed, &t.Unknown,
+		&t.Building, &t.Title, &t.Border, &t.Text, &t.Error,
+	} {
+		*style = f(*style)
+	}
+	return t
+}
+
+// xtermCubeLevels are the component values used by the 6x6x6 colour cube
+// in the xterm 256 colour palette.
+var xtermCubeLevels = []int{0, 95, 135, 175, 215, 255}
+
+// xtermSystemColours are the first 16 colours of the xterm palette.
+var xtermSystemColours = [][3]int{
+	{0x00, 0x00, 0x00}, {0x80, 0x00, 0x00}, {0x00, 0x80, 0x00}, {0x80, 0x80, 0x00},
+	{0x00, 0x00, 0x80}, {0x80, 0x00, 0x80}, {0x00, 0x80, 0x80}, {0xc0, 0xc0, 0xc0},
+	{0x80, 0x80, 0x80}, {0xff, 0x00, 0x00}, {0x00, 0xff, 0x00}, {0xff, 0xff, 0x00},
+	{0x00, 0x00, 0xff}, {0xff, 0x00, 0xff}, {0x00, 0xff, 0xff}, {0xff, 0xff, 0xff},
+}
+
+// xtermRGB returns the red, green and blue components of colour index of
+// the xterm 256 colour palette.
+func xtermRGB(index int) (r, g, b int) {
+	switch {
+	case index < 16:
+		colour := xtermSystemColours[index]
+		return colour[0], colour[1], colour[2]
+	case index < 232:
+		index -= 16
+		return xtermCubeLevels[index/36], xtermCubeLevels[(index/6)%6],
+			xtermCubeLevels[index%6]
+	}
+	grey := 8 + (index-232)*10
+	return grey, grey, grey
+}
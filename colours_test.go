@@ -0,0 +1,135 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var detectColourModeTests = []struct {
+	env  map[string]string
+	mode ColourMode
+}{
+	{map[string]string{"TERM": "xterm-256color"}, ColourMode256},
+	{map[string]string{"TERM": "screen-256color"}, ColourMode256},
+	{map[string]string{"TERM": "xterm", "COLORTERM": "truecolor"}, ColourModeTrueColour},
+	{map[string]string{"TERM": "xterm-256color", "COLORTERM": "24bit"}, ColourModeTrueColour},
+	{map[string]string{"TERM": "xterm"}, ColourMode16},
+	{map[string]string{"TERM": "rxvt-16color"}, ColourMode16},
+	{map[string]string{"TERM": "linux"}, ColourMode8},
+	{map[string]string{"TERM": "ansi"}, ColourMode8},
+	{map[string]string{"TERM": "vt100"}, ColourModeMonochrome},
+	{map[string]string{"TERM": "xterm-mono"}, ColourModeMonochrome},
+	{map[string]string{"TERM": "dumb"}, ColourModeMonochrome},
+	{map[string]string{}, ColourModeMonochrome},
+	{map[string]string{"TERM": "xterm-256color", "NO_COLOR": "1"}, ColourModeMonochrome},
+}
+
+func TestDetectColourModeUsesTheEnvironment(t *testing.T) {
+	for _, test := range detectColourModeTests {
+		mode := DetectColourMode(func(key string) string {
+			return test.env[key]
+		})
+		assert.Equal(t, test.mode, mode, "%v", test.env)
+	}
+}
+
+func TestParseColourModeRejectsUnknownModes(t *testing.T) {
+	mode, err := ParseColourMode("16")
+	assert.Nil(t, err)
+	assert.Equal(t, ColourMode16, mode)
+
+	_, err = ParseColourMode("lots")
+	assert.EqualError(t, err,
+		`unknown colour mode "lots", expected one of [auto truecolour 256 16 8 monochrome]`)
+}
+
+var quantiseTests = []struct {
+	in      Colour
+	colours int
+	out     Colour
+}{
+	{ColourDefault, 8, ColourDefault},
+	{ColourRed, 8, ColourRed},
+	{xtermColour(166), 8, ColourYellow},
+	{xtermColour(196), 8, ColourRed},
+	{xtermColour(196), 16, xtermColour(9)},
+	{xtermColour(9), 8, ColourRed},
+	{xtermColour(231) | AttrBold, 16, xtermColour(15) | AttrBold},
+	{xtermColour(234), 8, ColourBlack},
+	{RGBColour(0xd7, 0x5f, 0x00), 256, xtermColour(166)},
+	{RGBColour(0xd8, 0x60, 0x01) | AttrBold, 256, xtermColour(166) | AttrBold},
+	{RGBColour(0xff, 0x00, 0x00), 16, xtermColour(9)},
+	{RGBColour(0xff, 0x00, 0x00), 8, ColourRed},
+}
+
+func TestQuantisePicksTheClosestPaletteColourAndKeepsAttributes(t *testing.T) {
+	for _, test := range quantiseTests {
+		assert.Equal(t, test.out, test.in.quantise(test.colours),
+			"quantise(%d, %d)", test.in, test.colours)
+	}
+}
+
+func TestThemesOnlyUseColoursTheTerminalHas(t *testing.T) {
+	theme := BuiltinThemes["colour-blind"]
+
+	assert.Equal(t, theme, theme.forColourMode(ColourMode256))
+	eight := theme.forColourMode(ColourMode8)
+	assert.Equal(t, Style{xtermColour(15) | AttrBold, ColourYellow},
+		theme.forColourMode(ColourMode16).Failed)
+	assert.Equal(t, Style{ColourWhite | AttrBold, ColourYellow},
+		eight.Failed)
+	assert.Equal(t, Style{ColourWhite | AttrBold, ColourCyan},
+		eight.Passed)
+	assert.Equal(t, monochromeTheme, theme.forColourMode(ColourModeMonochrome))
+}
+
+func TestRGBColoursAreOnlyDrawnExactlyWithTrueColour(t *testing.T) {
+	theme := DefaultTheme
+	theme.Failed = Style{ColourWhite, RGBColour(0x00, 0xa3, 0xe0)}
+
+	assert.Equal(t, theme, theme.forColourMode(ColourModeTrueColour))
+	assert.Equal(t, Style{ColourWhite, xtermColour(38)},
+		theme.forColourMode(ColourMode256).Failed)
+	assert.Equal(t, Style{ColourWhite, ColourCyan},
+		theme.forColourMode(ColourMode8).Failed)
+}
+
+func TestMonochromeBuildsAreToldApartWithoutColour(t *testing.T) {
+	screen := NewVirtualScreen(40, 10)
+	dashboard := NewDashboard(nil, screen)
+	dashboard.ApplySettings(Settings{Colours: ColourModeMonochrome, Layout: DefaultLayout})
+
+	dashboard.drawWidget(dashboard.buildBox(build{name: "Failed", buildState: BuildStateFailed}),
+		NewRect(0, 0, 30, 4))
+	dashboard.drawWidget(dashboard.buildBox(build{name: "Passed", buildState: BuildStatePassed}),
+		NewRect(0, 4, 30, 4))
+
+	assertCellColours(t, screen, 2, 1, ColourDefault,
+		ColourDefault|AttrReverse,
+		"the default colour", "the reversed background")
+	assertCellColours(t, screen, 5, 2,
+		ColourDefault|AttrBold, ColourDefault|AttrReverse,
+		"the bold glyph", "the reversed background")
+	assertCellColours(t, screen, 2, 5, ColourDefault, ColourDefault,
+		"the default colour", "the default background")
+}
+
+var colourStringTests = []struct {
+	in  Colour
+	out string
+}{
+	{ColourDefault, "default"},
+	{ColourRed, "red"},
+	{ColourWhite | AttrBold, "white bold"},
+	{xtermColour(166) | AttrUnderline | AttrReverse, "166 underline reverse"},
+	{RGBColour(0xd7, 0x5f, 0x00), "#d75f00"},
+}
+
+func TestColourStringIsHowItIsWrittenInTheConfigFile(t *testing.T) {
+	for _, test := range colourStringTests {
+		assert.Equal(t, test.out, test.in.String())
+		colour, err := parseColour(test.out)
+		assert.Nil(t, err)
+		assert.Equal(t, test.in, colour, test.out)
+	}
+}
@@ -0,0 +1,408 @@
+package monitrondashboard
+
+// Configuration for the monitron dashboard.
+// Here you'll find code for loading the YAML config file, checking it
+// and applying the named profile that's been asked for.
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Config is the contents of a config file, the default settings and any
+// named profiles that change them.
+type Config struct {
+	Settings `yaml:",inline"`
+	Profiles map[string]Settings `yaml:"profiles"`
+}
+
+// Settings are everything that can be set in a config file or profile.
+type Settings struct {
+	View `yaml:",inline"`
+
+	Servers        []ServerConfig       `yaml:"servers"`
+	Output         OutputMode           `yaml:"output"`
+	MetricsAddress string               `yaml:"metrics_address"`
+	Theme          string               `yaml:"theme"`
+	Themes         map[string]Theme     `yaml:"themes"`
+	Colours        ColourMode           `yaml:"colours"`
+	Backend        Backend              `yaml:"backend"`
+	Layout         LayoutSettings       `yaml:"layout"`
+	Keys           KeyBindings          `yaml:"keys"`
+	Status         StatusSettings       `yaml:"status"`
+	Notifications  NotificationSettings `yaml:"notifications"`
+}
+
+// ServerConfig is a Monitron server to connect to, the name is shown as
+// the source of its builds when there is more than one server.
+type ServerConfig struct {
+	Name    string `yaml:"name"`
+	Address string `yaml:"address"`
+}
+
+// LayoutSettings are the sizes used to lay out the dashboard's grid of
+// build boxes.
+type LayoutSettings struct {
+	// BoxWidth is the narrowest a build box can be, the boxes are
+	// widened to fill the screen.
+	BoxWidth int `yaml:"box_width"`
+	// Padding is the space between the boxes.
+	Padding int `yaml:"padding"`
+	// Template is the rows of details shown in each build box, the
+	// DefaultBoxTemplate if it is empty.
+	Template BoxTemplate `yaml:"template"`
+}
+
+// boxTemplate returns the template for the build boxes.
+func (l LayoutSettings) boxTemplate() BoxTemplate {
+	if len(l.Template) == 0 {
+		return DefaultBoxTemplate
+	}
+	return l.Template
+}
+
+// StatusSettings are the settings for the status output.
+type StatusSettings struct {
+	Tmux     bool          `yaml:"tmux"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// NotificationSettings are the hooks to run when builds fail, recover
+// or are escalated.
+type NotificationSettings struct {
+	OnTransition    string        `yaml:"on_transition"`
+	NotifyCommand   string        `yaml:"notify_command"`
+	Bell            bool          `yaml:"bell"`
+	Webhooks        []Webhook     `yaml:"webhooks"`
+	WebhookDebounce time.Duration `yaml:"webhook_debounce"`
+	EscalateAfter   time.Duration `yaml:"escalate_after"`
+	OnEscalation    string        `yaml:"on_escalation"`
+}
+
+// OutputMode is how builds are shown: dashboard, text, status or json.
+type OutputMode string
+
+// outputModes are the known OutputModes.
+var outputModes = []string{"dashboard", "text", "status", "json"}
+
+// DefaultSettings returns the settings used for anything a config file
+// doesn't set.
+func DefaultSettings() Settings {
+	return Settings{
+		Output:  "dashboard",
+		Colours: ColourModeAuto,
+		Backend: BackendTermbox,
+		Layout:  DefaultLayout,
+		Keys:    DefaultKeyBindings,
+		Notifications: NotificationSettings{
+			WebhookDebounce: 30 * time.Second,
+		},
+	}
+}
+
+// CurrentTheme returns the chosen theme, or the DefaultTheme if none is
+// chosen.
+func (s Settings) CurrentTheme() Theme {
+	if theme, ok := s.LookupTheme(s.Theme); ok {
+		return theme
+	}
+	return DefaultTheme
+}
+
+// CurrentColourMode returns the chosen colour mode, detecting it from
+// the environment unless one is given.
+func (s Settings) CurrentColourMode() ColourMode {
+	if s.Colours == "" || s.Colours == ColourModeAuto {
+		return DetectColourMode(os.Getenv)
+	}
+	return s.Colours
+}
+
+// LookupTheme returns the theme called name from the themes in the
+// settings or the BuiltinThemes, and whether it was found.
+func (s Settings) LookupTheme(name string) (Theme, bool) {
+	if theme, ok := s.Themes[name]; ok {
+		return theme, true
+	}
+	theme, ok := BuiltinThemes[name]
+	return theme, ok
+}
+
+// DefaultConfigPath returns where the config file is looked for when
+// one isn't given, monidash/config.yaml in the XDG config directory.
+func DefaultConfigPath() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(configHome, "monidash", "config.yaml")
+}
+
+// LoadConfig reads the config file at path and returns its settings,
+// changed by the named profile unless profile is empty.
+func LoadConfig(path string, profile string) (Settings, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Settings{}, err
+	}
+	settings, err := parseConfig(data, profile)
+	if err != nil {
+		return Settings{}, fmt.Errorf("%s: %s", path, err)
+	}
+	return settings, nil
+}
+
+// parseConfig parses and checks a config file, returning its settings
+// changed by the named profile unless profile is empty.
+func parseConfig(data []byte, profile string) (Settings, error) {
+	config := Config{Settings: DefaultSettings()}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && err != io.EOF {
+		return Settings{}, err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return Settings{}, err
+	}
+	var root *yaml.Node
+	if len(document.Content) > 0 {
+		root = document.Content[0]
+	}
+	settings := config.Settings
+	if err := checkTheme(settings, root); err != nil {
+		return Settings{}, err
+	}
+	if profile == "" {
+		return settings, nil
+	}
+
+	if _, ok := config.Profiles[profile]; !ok {
+		return Settings{}, fmt.Errorf("unknown profile %q", profile)
+	}
+	// Decoding the profile over the top of the other settings only
+	// changes the settings the profile gives.
+	profileNode := mappingValue(mappingValue(root, "profiles"), profile)
+	if profileNode.Kind != yaml.MappingNode {
+		return settings, nil
+	}
+	if err := profileNode.Decode(&settings); err != nil {
+		return Settings{}, err
+	}
+	if err := checkTheme(settings, profileNode); err != nil {
+		return Settings{}, err
+	}
+	return settings, nil
+}
+
+// checkTheme returns an error if the theme chosen in settings doesn't
+// exist, using the line of the theme in mapping.
+func checkTheme(settings Settings, mapping *yaml.Node) error {
+	themeNode := mappingValue(mapping, "theme")
+	if themeNode == nil {
+		return nil
+	}
+	_, err := decodeChoice(themeNode, "theme", themeNames(settings.Themes))
+	return err
+}
+
+// structFields returns the YAML names of the fields of the struct out
+// points to.
+func structFields(out interface{}) []string {
+	structType := reflect.TypeOf(out).Elem()
+	fields := make([]string, structType.NumField())
+	for i := range fields {
+		fields[i] = strings.Split(structType.Field(i).Tag.Get("yaml"), ",")[0]
+	}
+	return fields
+}
+
+// checkFields returns an error for the first key in a YAML mapping that
+// isn't one of fields. Node.Decode doesn't check for unknown fields like
+// the config decoder does, so UnmarshalYAML methods check for themselves.
+func checkFields(mapping *yaml.Node, fields []string) error {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		known := false
+		for _, field := range fields {
+			if key.Value == field {
+				known = true
+			}
+		}
+		if !known {
+			return configError(key, "unknown field %q, expected one of %v", key.Value, fields)
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value for key in a YAML mapping, or nil if
+// it isn't there.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// configError returns an error for a bad value at the line of node.
+func configError(node *yaml.Node, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", node.Line, fmt.Sprintf(format, args...))
+}
+
+// decodeChoice decodes a string from value, returning an error unless it
+// is one of choices.
+func decodeChoice(value *yaml.Node, what string, choices []string) (string, error) {
+	var choice string
+	if err := value.Decode(&choice); err != nil {
+		return "", err
+	}
+	for _, known := range choices {
+		if choice == known {
+			return choice, nil
+		}
+	}
+	return "", configError(value, "unknown %s %q, expected one of %v", what, choice, choices)
+}
+
+// UnmarshalYAML accepts a server given as just an address, or as a
+// mapping with a name and address.
+func (s *ServerConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*s = ServerConfig{Name: value.Value, Address: value.Value}
+	} else {
+		if err := checkFields(value, structFields(s)); err != nil {
+			return err
+		}
+		type plainServerConfig ServerConfig
+		if err := value.Decode((*plainServerConfig)(s)); err != nil {
+			return err
+		}
+		if s.Name == "" {
+			s.Name = s.Address
+		}
+	}
+	if s.Address == "" {
+		return configError(value, "server has no address")
+	}
+	return nil
+}
+
+// UnmarshalYAML checks the output mode is one of the outputModes.
+func (m *OutputMode) UnmarshalYAML(value *yaml.Node) error {
+	mode, err := decodeChoice(value, "output mode", outputModes)
+	*m = OutputMode(mode)
+	return err
+}
+
+// UnmarshalYAML checks the colour mode is one of the colourModes.
+func (m *ColourMode) UnmarshalYAML(value *yaml.Node) error {
+	mode, err := decodeChoice(value, "colour mode", colourModes)
+	*m = ColourMode(mode)
+	return err
+}
+
+// UnmarshalYAML checks the backend is one of the backends.
+func (b *Backend) UnmarshalYAML(value *yaml.Node) error {
+	backend, err := decodeChoice(value, "backend", backends)
+	*b = Backend(backend)
+	return err
+}
+
+// UnmarshalYAML checks the boxes are wide enough to draw the builds in.
+func (l *LayoutSettings) UnmarshalYAML(value *yaml.Node) error {
+	if err := checkFields(value, structFields(l)); err != nil {
+		return err
+	}
+	type plainLayoutSettings LayoutSettings
+	if err := value.Decode((*plainLayoutSettings)(l)); err != nil {
+		return err
+	}
+	if node := mappingValue(value, "box_width"); node != nil && l.BoxWidth < minimumBoxWidth {
+		return configError(node, "box_width must be at least %d", minimumBoxWidth)
+	}
+	if node := mappingValue(value, "padding"); node != nil && l.Padding < 0 {
+		return configError(node, "padding can't be negative")
+	}
+	return nil
+}
+
+// UnmarshalYAML checks the sort order is one of the sortOrders.
+func (o *SortOrder) UnmarshalYAML(value *yaml.Node) error {
+	order, err := decodeChoice(value, "sort order", sortOrders)
+	*o = SortOrder(order)
+	return err
+}
+
+// UnmarshalYAML checks the pattern is a valid shell pattern.
+func (p *Pattern) UnmarshalYAML(value *yaml.Node) error {
+	var pattern string
+	if err := value.Decode(&pattern); err != nil {
+		return err
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return configError(value, "bad pattern %q", pattern)
+	}
+	*p = Pattern(pattern)
+	return nil
+}
+
+// UnmarshalYAML decodes a build state from its name.
+func (bs *buildState) UnmarshalYAML(value *yaml.Node) error {
+	names := make([]string, len(buildStates))
+	for i, state := range buildStates {
+		names[i] = state.String()
+	}
+	name, err := decodeChoice(value, "build state", names)
+	for _, state := range buildStates {
+		if state.String() == name {
+			*bs = state
+		}
+	}
+	return err
+}
+
+// UnmarshalYAML checks the key is a single character or "space".
+func (k *Key) UnmarshalYAML(value *yaml.Node) error {
+	var key string
+	if err := value.Decode(&key); err != nil {
+		return err
+	}
+	if key != "space" && utf8.RuneCountInString(key) != 1 {
+		return configError(value, "bad key %q, expected a single character or space", key)
+	}
+	*k = Key(key)
+	return nil
+}
+
+// UnmarshalYAML decodes a webhook in the same form as ParseWebhook.
+func (w *Webhook) UnmarshalYAML(value *yaml.Node) error {
+	var webhook string
+	if err := value.Decode(&webhook); err != nil {
+		return err
+	}
+	parsed, err := ParseWebhook(webhook)
+	if err != nil {
+		return configError(value, "%s", err)
+	}
+	*w = parsed
+	return nil
+}
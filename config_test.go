@@ -0,0 +1,175 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+servers:
+  - localhost:9988
+  - name: ci
+    address: ci.example.com:9988
+output: dashboard
+sort: state
+filter:
+  exclude: ["*-nightly"]
+groups:
+  - builds: ["payments-*"]
+keys:
+  quit: x
+notifications:
+  bell: true
+  webhooks: ["slack=https://hooks.example.com/builds"]
+  escalate_after: 30m
+profiles:
+  payments-wall:
+    output: text
+    filter:
+      include: ["payments-*"]
+      states: [failed, acknowledged]
+    keys:
+      step: space
+`
+
+func TestParseConfigReadsTheTopLevelSettings(t *testing.T) {
+	settings, err := parseConfig([]byte(testConfig), "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []ServerConfig{
+		{Name: "localhost:9988", Address: "localhost:9988"},
+		{Name: "ci", Address: "ci.example.com:9988"},
+	}, settings.Servers)
+	assert.Equal(t, OutputMode("dashboard"), settings.Output)
+	assert.Equal(t, SortByState, settings.Sort)
+	assert.Equal(t, []Pattern{"*-nightly"}, settings.Filter.Exclude)
+	assert.Equal(t, []Group{{Builds: []Pattern{"payments-*"}}},
+		settings.Groups)
+	assert.Equal(t, KeyBindings{Quit: "x", Pause: "space", Step: "n"}, settings.Keys)
+	assert.True(t, settings.Notifications.Bell)
+	assert.Equal(t, []Webhook{{URL: "https://hooks.example.com/builds", Format: "slack"}},
+		settings.Notifications.Webhooks)
+	assert.Equal(t, 30*time.Minute, settings.Notifications.EscalateAfter)
+	assert.Equal(t, 30*time.Second, settings.Notifications.WebhookDebounce,
+		"Settings not in the file should keep their defaults")
+}
+
+func TestParseConfigAppliesTheProfileOverTheTopLevelSettings(t *testing.T) {
+	settings, err := parseConfig([]byte(testConfig), "payments-wall")
+
+	assert.Nil(t, err)
+	assert.Equal(t, OutputMode("text"), settings.Output)
+	assert.Equal(t, []Pattern{"payments-*"}, settings.Filter.Include)
+	assert.Equal(t, []buildState{BuildStateFailed, BuildStateAcknowledged},
+		settings.Filter.States)
+	assert.Equal(t, []Pattern{"*-nightly"}, settings.Filter.Exclude)
+	assert.Equal(t, KeyBindings{Quit: "x", Pause: "space", Step: "space"}, settings.Keys)
+	assert.Equal(t, SortByState, settings.Sort)
+	assert.Len(t, settings.Servers, 2)
+}
+
+func TestParseConfigAppliesLayoutsFromProfilesOverTheTopLevelLayout(t *testing.T) {
+	config := "layout:\n  box_width: 40\nprofiles:\n  wall:\n    layout:\n      padding: 2\n"
+
+	settings, err := parseConfig([]byte(config), "wall")
+
+	assert.Nil(t, err)
+	assert.Equal(t, LayoutSettings{BoxWidth: 40, Padding: 2}, settings.Layout)
+}
+
+func TestParseConfigReadsTheBoxTemplate(t *testing.T) {
+	config := "layout:\n  template:\n    - name\n    - acknowledger failing_since:right\n"
+
+	settings, err := parseConfig([]byte(config), "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, BoxTemplate{
+		{{Name: "name"}},
+		{{Name: "acknowledger"}, {Name: "failing_since", Align: AlignRight}},
+	}, settings.Layout.boxTemplate())
+	assert.Equal(t, DefaultBoxTemplate, DefaultLayout.boxTemplate())
+}
+
+func TestParseConfigRejectsUnknownProfiles(t *testing.T) {
+	_, err := parseConfig([]byte(testConfig), "lobby")
+
+	assert.EqualError(t, err, `unknown profile "lobby"`)
+}
+
+func TestParseConfigOfAnEmptyFileGivesTheDefaultSettings(t *testing.T) {
+	settings, err := parseConfig([]byte(""), "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultSettings(), settings)
+}
+
+var invalidConfigTests = []struct {
+	config string
+	err    string
+}{
+	{"output: grid\n",
+		`line 1: unknown output mode "grid", expected one of [dashboard text status json]`},
+	{"servers:\n  - name: ci\n", "line 2: server has no address"},
+	{"sort: colour\n",
+		`line 1: unknown sort order "colour", expected one of [server name state failing-since]`},
+	{"filter:\n  include: [\"payments-[\"]\n", `line 2: bad pattern "payments-["`},
+	{"filter:\n  states: [broken]\n",
+		`line 2: unknown build state "broken", expected one of [failed acknowledged passed unknown]`},
+	{"keys:\n  quit: escape\n",
+		`line 2: bad key "escape", expected a single character or space`},
+	{"notifications:\n  webhooks: [\"irc=example.com\"]\n",
+		"line 2: Unknown webhook format: irc"},
+	{"profiles:\n  wall:\n    output: grid\n",
+		`line 3: unknown output mode "grid", expected one of [dashboard text status json]`},
+	{"address: localhost\n",
+		"yaml: unmarshal errors:\n  line 1: field address not found in type monitrondashboard.Config"},
+	{"notifications:\n  escalate_after: soon\n",
+		"yaml: unmarshal errors:\n  line 2: cannot unmarshal !!str `soon` into time.Duration"},
+	{"colours: 24\n",
+		`line 1: unknown colour mode "24", expected one of [auto truecolour 256 16 8 monochrome]`},
+	{"backend: curses\n",
+		`line 1: unknown backend "curses", expected one of [termbox tcell]`},
+	{"layout:\n  box_width: 10\n", "line 2: box_width must be at least 20"},
+	{"layout:\n  padding: -1\n", "line 2: padding can't be negative"},
+	{"layout:\n  box_widht: 40\n",
+		`line 2: unknown field "box_widht", expected one of [box_width padding template]`},
+	{"layout:\n  template:\n    - name\n    - failures urls\n",
+		`line 4: unknown template field "urls", expected one of [name state acknowledger ` +
+			`building failures failing_since source url_host]`},
+	{"layout:\n  template:\n    - name:middle\n",
+		`line 3: unknown alignment "middle", expected one of [left centre right]`},
+	{"servers:\n  - adress: ci:9988\n",
+		`line 2: unknown field "adress", expected one of [name address]`},
+	{"output: [\n", "yaml: line 1: did not find expected node content"},
+}
+
+func TestParseConfigGivesLineNumbersForInvalidConfig(t *testing.T) {
+	for _, test := range invalidConfigTests {
+		_, err := parseConfig([]byte(test.config), "")
+		assert.EqualError(t, err, test.err, test.config)
+	}
+}
+
+func TestLoadConfigPrefixesErrorsWithThePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "monidash")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("output: grid\n"), 0644))
+
+	_, err = LoadConfig(path, "")
+
+	assert.EqualError(t, err, path+
+		`: line 1: unknown output mode "grid", expected one of [dashboard text status json]`)
+}
+
+func TestDefaultConfigPathUsesXDGConfigHome(t *testing.T) {
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", "/etc/xdg")
+
+	assert.Equal(t, "/etc/xdg/monidash/config.yaml", DefaultConfigPath())
+}
@@ -7,14 +7,30 @@ package monitrondashboard
 import (
 	"errors"
 	"fmt"
-	"github.com/nsf/termbox-go"
+	"io"
+	"strings"
+	"time"
 )
 
-const OrangeColour int = 167
+const buildingMessage string = "Building"
 
-const textPadding int = 1
+// swatchWidth is the width of the swatch in the colour of a build's state
+// at the left of its box.
+const swatchWidth int = 8
 
-const buildingMessage string = "Building"
+// minimumBoxWidth is the narrowest a build box can be drawn.
+const minimumBoxWidth int = 20
+
+// DefaultLayout is the layout used unless it is changed.
+var DefaultLayout = LayoutSettings{
+	BoxWidth: 30,
+	Padding:  1,
+}
+
+// tickInterval is how often the dashboard redraws without an update, so
+// anything it shows that depends on the time stays current, and how often
+// the borders of escalated builds flash.
+const tickInterval = 500 * time.Millisecond
 
 // buildState is an int type defining the states a build can be in.
 type buildState int
@@ -26,36 +42,31 @@ const (
 	BuildStateUnknown
 )
 
-// BgColour returns the termbox Attribute for the background colour
-// of a build in this state.
-func (bs buildState) BgColour() termbox.Attribute {
+// String returns the lower case name of the build state.
+func (bs buildState) String() string {
 	switch bs {
 	case BuildStateFailed:
-		return termbox.ColorRed
+		return "failed"
 	case BuildStateAcknowledged:
-		return termbox.Attribute(OrangeColour)
+		return "acknowledged"
 	case BuildStatePassed:
-		return termbox.ColorGreen
-	case BuildStateUnknown:
-		return termbox.ColorMagenta
+		return "passed"
 	}
-	return termbox.ColorCyan
+	return "unknown"
 }
 
-// BgColour returns the termbox Attribute for the text colour
-// of a build in this state.
-func (bs buildState) FgColour() termbox.Attribute {
+// glyph returns the glyph drawn in the swatch of a build in this state,
+// so the state can be told without seeing its colour.
+func (bs buildState) glyph() rune {
 	switch bs {
 	case BuildStateFailed:
-		return termbox.ColorWhite
+		return '✗'
 	case BuildStateAcknowledged:
-		return termbox.ColorWhite
+		return '!'
 	case BuildStatePassed:
-		return termbox.ColorWhite
-	case BuildStateUnknown:
-		return termbox.ColorWhite
+		return '✓'
 	}
-	return termbox.ColorWhite
+	return '?'
 }
 
 // build is a struct covering a build and its current state.
@@ -64,6 +75,14 @@ type build struct {
 	buildState   buildState
 	building     bool
 	acknowledger string
+	failingSince time.Time
+	// failures is how many times in a row the build has failed.
+	failures  int
+	url       string
+	escalated bool
+	// source is the name of the server the build came from, when builds
+	// from more than one server are merged.
+	source string
 }
 
 // rect is a simple struct giving a bounding rectangle for a widget
@@ -106,26 +125,14 @@ type size struct {
 }
 
 // CellDrawer is an interface for drawing Cells on the screen,
-// we use this wrap the termbox module level api to something a little
+// we use this wrap the terminal library to something a little
 // more testable.
 type CellDrawer interface {
-	// SetCell draws rune ch at x, y with foreground and background attributes.
-	SetCell(x, y int, ch rune, fg, bg termbox.Attribute)
+	// SetCell draws rune ch at x, y with foreground and background colours.
+	SetCell(x, y int, ch rune, fg, bg Colour)
 	Flush()
 }
 
-// TermboxCellDrawer implements CellDrawer using termbox to draw on the terminal
-// screen.
-type TermboxCellDrawer struct{}
-
-func (t TermboxCellDrawer) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
-	termbox.SetCell(x, y, ch, fg, bg)
-}
-
-func (t TermboxCellDrawer) Flush() {
-	termbox.Flush()
-}
-
 // RuneWriter is a function that returns the rune that should be drawn at point,
 // the current rune `char` is passed into the RuneWriter so it can return that
 // if this writer doesn't want to draw a rune, allowing for chaining of writers
@@ -133,12 +140,12 @@ type RuneWriter func(char rune, point point) rune
 
 // AttributeWriter is a function that returns the attributes that should be applied
 // to a particular cell.
-type AttributeWriter func(fg, bg termbox.Attribute, point point) (termbox.Attribute, termbox.Attribute)
+type AttributeWriter func(fg, bg Colour, point point) (Colour, Colour)
 
 // createBoxFillWriter creates a AttributeWriter that draws a box filled in with
 // colour for the rectangle marked by rect.
-func createBoxFillWriter(rect rect, colour termbox.Attribute) AttributeWriter {
-	return func(fg, bg termbox.Attribute, point point) (termbox.Attribute, termbox.Attribute) {
+func createBoxFillWriter(rect rect, colour Colour) AttributeWriter {
+	return func(fg, bg Colour, point point) (Colour, Colour) {
 		if rect.PointWithinRect(point) {
 			return fg, colour
 		} else {
@@ -147,6 +154,32 @@ func createBoxFillWriter(rect rect, colour termbox.Attribute) AttributeWriter {
 	}
 }
 
+// createStyleWriter creates an AttributeWriter that draws the cells in the
+// rectangle marked by rect with style.
+func createStyleWriter(rect rect, style Style) AttributeWriter {
+	return func(fg, bg Colour, point point) (Colour, Colour) {
+		if rect.PointWithinRect(point) {
+			return style.Fg, style.Bg
+		}
+		return fg, bg
+	}
+}
+
+// createBorderColourWriter creates an AttributeWriter that colours the
+// border of the rectangle marked by rect.
+func createBorderColourWriter(rect rect, colour Colour) AttributeWriter {
+	return func(fg, bg Colour, point point) (Colour, Colour) {
+		onVerticalEdge := (point.x == rect.x || point.x == rect.x+rect.w-1) &&
+			point.y >= rect.y && point.y < rect.y+rect.h
+		onHorizontalEdge := (point.y == rect.y || point.y == rect.y+rect.h-1) &&
+			point.x >= rect.x && point.x < rect.x+rect.w
+		if onVerticalEdge || onHorizontalEdge {
+			return colour, bg
+		}
+		return fg, bg
+	}
+}
+
 // createTextPrinter takes a string and a starting point and returns a function,
 // the returned function takes the current char to be displayed and a point and
 // will return the the character this printer thinks should be displayed at this point.
@@ -233,6 +266,9 @@ func layoutGridForScreen(minimumBoxSize size, numberOfBoxes int, padding int, bo
 	}
 
 	maximumNumberOfVerticalBoxes := (bounds.h - padding) / (minimumBoxSize.h + padding)
+	if maximumNumberOfVerticalBoxes < 1 {
+		return Layout{}, errors.New("Screen is too small to fit the grid")
+	}
 	// integer division that always rounds up
 	requiredNumberOfColumns := (numberOfBoxes + maximumNumberOfVerticalBoxes - 1) / maximumNumberOfVerticalBoxes
 	columnWidth := (bounds.w - padding) / requiredNumberOfColumns
@@ -267,41 +303,115 @@ gridloop:
 
 // Dashboard interface that can draw to any CellDrawer interface.
 type Dashboard struct {
-	builds     []build
-	err        error
-	cellDrawer CellDrawer
-	fetcher    BuildFetcher
+	builds []build
+	err    error
+	screen Screen
+	// events is where input events come from, the screen unless
+	// replaced with SetEventSource.
+	events  EventSource
+	fetcher BuildFetcher
+	clock   Clock
+	// logChannel receives the lines written to the LogWriter, such as
+	// errors running notification hooks, logMessage is the last one.
+	logChannel chan string
+	logMessage string
+	keys       KeyBindings
+	layout     LayoutSettings
+	// colours is the colour mode the theme has been converted for.
+	colours ColourMode
+	theme   Theme
+	// flash alternates to flash the borders of escalated builds.
+	flash bool
+	// configChannel receives the settings when the config file is
+	// reloaded, configErr is the error from the last reload.
+	configChannel chan ConfigUpdate
+	configErr     error
 }
 
-// NewDashboard creates a new Dashboard using the provided CellDrawer
-// to draw to the screen.
-func NewDashboard(fetcher BuildFetcher, cellDrawer CellDrawer) Dashboard {
+// NewDashboard creates a new Dashboard drawing on screen.
+func NewDashboard(fetcher BuildFetcher, screen Screen) Dashboard {
 	dashboard := Dashboard{
 		fetcher:    fetcher,
 		builds:     []build{},
-		cellDrawer: cellDrawer,
+		screen:     screen,
+		events:     screen,
+		clock:      SystemClock,
+		logChannel: make(chan string, 10),
+		keys:       DefaultKeyBindings,
+		layout:     DefaultLayout,
+		colours:    ColourMode256,
+		theme:      DefaultTheme,
 	}
 
 	return dashboard
 }
 
+// LogWriter returns a writer whose lines are shown on the bottom row of
+// the dashboard while it runs, for logging to while the dashboard owns
+// the terminal. Lines are dropped rather than waiting for the dashboard
+// if it falls behind.
+func (d *Dashboard) LogWriter() io.Writer {
+	return dashboardLog(d.logChannel)
+}
+
+// dashboardLog is the writer returned by LogWriter.
+type dashboardLog chan string
+
+func (l dashboardLog) Write(p []byte) (int, error) {
+	select {
+	case l <- strings.TrimRight(string(p), "\n"):
+	default:
+	}
+	return len(p), nil
+}
+
+// ApplySettings changes the keys the dashboard responds to, and how it
+// lays out and colours the builds.
+func (d *Dashboard) ApplySettings(settings Settings) {
+	d.keys = settings.Keys
+	d.layout = settings.Layout
+	d.colours = settings.CurrentColourMode()
+	d.theme = settings.CurrentTheme().forColourMode(d.colours)
+}
+
+// SetConfigChannel gives the dashboard a channel to receive the settings
+// on while it runs, whenever the config file is reloaded.
+func (d *Dashboard) SetConfigChannel(configChannel chan ConfigUpdate) {
+	d.configChannel = configChannel
+}
+
+// SetEventSource replaces the screen as the source of the dashboard's
+// input events, for driving it from a script in tests.
+func (d *Dashboard) SetEventSource(events EventSource) {
+	d.events = events
+}
+
+// SetClock replaces the system clock the dashboard ticks with, for
+// controlling time in tests.
+func (d *Dashboard) SetClock(clock Clock) {
+	d.clock = clock
+}
+
 // run runs the dashboard event loop, redrawing the screen;  responding
-// to input events and updating based on new build information
-func (d *Dashboard) Run() {
-	err := termbox.Init()
+// to input events and updating based on new build information. It returns
+// an error if the screen can't be drawn on, once the screen is closed.
+func (d *Dashboard) Run() error {
+	err := d.screen.Init(d.colours)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer termbox.Close()
-	termbox.SetInputMode(termbox.InputEsc)
-	termbox.SetOutputMode(termbox.Output256)
+	defer d.screen.Close()
+	d.clear()
 	if err := d.redraw(); err != nil {
-		fmt.Println("Error: %s", err)
-		return
+		return err
 	}
-	eventChannel := make(chan termbox.Event, 10)
-	go d.termboxEventPoller(eventChannel)
+	eventChannel := make(chan Event, 10)
+	go d.eventPoller(eventChannel)
+
+	ticker := d.clock.NewTicker(tickInterval)
+	defer ticker.Stop()
 
+	buildChannel := d.fetcher.BuildChannel()
 mainloop:
 	for {
 		select {
@@ -310,147 +420,279 @@ mainloop:
 				break mainloop
 			}
 			switch ev.Type {
-			case termbox.EventKey:
-				switch ev.Key {
-				case termbox.KeyEsc:
+			case EventKey:
+				if ev.Key == KeyEsc || d.keys.Quit.matches(ev) {
 					break mainloop
-				default:
-					if ev.Ch == 'q' {
-						break mainloop
-					}
 				}
-			case termbox.EventError:
-				fmt.Printf("Error: %s\n", ev.Err)
-				break mainloop
-			case termbox.EventResize:
-				termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
-				if err := d.redraw(); err != nil {
-					fmt.Println("Error: %s", err)
-					return
+				d.handlePlaybackKey(ev)
+			case EventError:
+				return ev.Err
+			case EventResize:
+				if screen, ok := d.screen.(resizable); ok {
+					screen.Resize(ev.Width, ev.Height)
+				}
+				if err := d.resize(); err != nil {
+					return err
 				}
 			}
 			if err := d.redraw(); err != nil {
-				fmt.Println("Error: %s", err)
-				return
+				return err
+			}
+		case <-ticker.C():
+			if !d.changesOverTime() {
+				continue
+			}
+			d.flash = !d.flash
+			if err := d.redraw(); err != nil {
+				return err
+			}
+		case buildUpdate, ok := <-buildChannel:
+			if !ok {
+				// The fetcher has finished, e.g. at the end of a replay,
+				// so keep showing the last builds.
+				buildChannel = nil
+				continue
+			}
+			if err := d.updateBuilds(buildUpdate); err != nil {
+				return err
 			}
-		case buildUpdate := <-d.fetcher.BuildChannel():
-			d.builds = buildUpdate.builds
-			d.err = buildUpdate.err
+		case message := <-d.logChannel:
+			d.logMessage = message
+			d.clear()
 			if err := d.redraw(); err != nil {
-				fmt.Println("Error: %s", err)
-				return
+				return err
+			}
+		case configUpdate := <-d.configChannel:
+			d.configErr = configUpdate.Err
+			if configUpdate.Err == nil {
+				d.ApplySettings(configUpdate.Settings)
+				d.screen.SetColourMode(d.colours)
+			}
+			d.clear()
+			if err := d.redraw(); err != nil {
+				return err
 			}
 		}
 	}
+	return nil
 }
 
-// redraw redraws the screen.
-func (d Dashboard) redraw() error {
-	screenWidth, screenHeight := termbox.Size()
-
-	d.drawTitle(screenWidth)
-	if d.err == nil {
-		bounds := NewRect(0, 1, screenWidth, screenHeight-1)
-		if err := d.drawBuilds(bounds); err != nil {
-			d.err = err
-			d.drawError()
-		}
+// updateBuilds shows the builds and error from buildUpdate, clearing
+// the screen so nothing is left of an error or a build that has gone.
+func (d *Dashboard) updateBuilds(buildUpdate BuildUpdate) error {
+	d.builds = buildUpdate.builds
+	d.err = buildUpdate.err
+	d.clear()
+	return d.redraw()
+}
 
-	} else {
-		d.drawError()
-	}
+// resizable is a Screen that is resized by the dashboard when it handles
+// a resize event, such as a VirtualScreen, rather than by a terminal.
+type resizable interface {
+	Resize(width, height int)
+}
 
-	termbox.Flush()
-	return nil
+// resize redraws the whole screen after it has changed size.
+func (d *Dashboard) resize() error {
+	d.clear()
+	return d.redraw()
 }
 
-// drawTitle draws the MONITRON title at the top of the terminal screen
-func (d Dashboard) drawTitle(screenWidth int) {
-	title := "MONITRON 5000"
-	xOffset := (screenWidth - len(title)) / 2
-	for i, char := range title {
-		d.cellDrawer.SetCell(i+xOffset, 1, char, termbox.ColorWhite, termbox.ColorBlack)
+// Key is a key that can be bound to a dashboard action, either a single
+// character or "space".
+type Key string
+
+// matches returns true if ev is a press of the key.
+func (k Key) matches(ev Event) bool {
+	if k == "space" {
+		return ev.Key == KeySpace
 	}
+	return ev.Key == KeyRune && string(ev.Ch) == string(k)
+}
 
+// KeyBindings are the keys for each of the dashboard's actions, escape
+// always quits as well.
+type KeyBindings struct {
+	Quit  Key `yaml:"quit"`
+	Pause Key `yaml:"pause"`
+	Step  Key `yaml:"step"`
 }
 
-// drawBuilds draws a grid of build info boxes inside the rectangle dictated by
-// bounds, it returns an error if bounds is too small to fit the builds
-func (d Dashboard) drawBuilds(bounds rect) error {
+// DefaultKeyBindings are the keys used unless they are changed.
+var DefaultKeyBindings = KeyBindings{
+	Quit:  "q",
+	Pause: "space",
+	Step:  "n",
+}
 
-	numberOfBuilds := len(d.builds)
-	layout, err := layoutGridForScreen(size{30, 5}, numberOfBuilds, 1,
-		bounds)
-	if err != nil {
-		return err
+// handlePlaybackKey pauses or steps through the builds if the fetcher
+// supports it and ev is the pause or step key.
+func (d Dashboard) handlePlaybackKey(ev Event) {
+	controller, ok := d.fetcher.(PlaybackController)
+	if !ok {
+		return
+	}
+	switch {
+	case d.keys.Pause.matches(ev):
+		controller.TogglePause()
+	case d.keys.Step.matches(ev):
+		controller.Step()
 	}
+}
 
-	for i := 0; i < numberOfBuilds; i++ {
-		box := layout.boxes[i]
-		d.drawBuildState(d.builds[i], box)
+// clear clears the screen to the theme's background.
+func (d Dashboard) clear() {
+	d.screen.Clear(d.theme.Text.Fg, d.theme.Text.Bg)
+}
+
+// redraw redraws the screen, showing the error in place of the builds
+// if there are none.
+func (d Dashboard) redraw() error {
+	screenWidth, screenHeight := d.screen.Size()
+	bounds := NewRect(0, 0, screenWidth, screenHeight)
+
+	if err := d.drawWidget(d.screenWidget(), bounds); err != nil {
+		d.err, d.builds = err, nil
+		d.drawWidget(d.screenWidget(), bounds)
 	}
+
+	d.screen.Flush()
 	return nil
 }
 
-func (d Dashboard) drawError() error {
-	errorString := fmt.Sprintf("Error: %s", d.err.Error())
-	for i, char := range errorString {
-		d.cellDrawer.SetCell(i, 3, char, termbox.ColorWhite, termbox.ColorBlack)
+// drawWidget lays out widget within bounds and draws it, returning an
+// error without drawing anything if it doesn't fit.
+func (d Dashboard) drawWidget(widget Widget, bounds rect) error {
+	if err := widget.Layout(bounds); err != nil {
+		return err
 	}
+	canvas := NewCanvas(bounds, d.theme.Text)
+	widget.Draw(canvas)
+	canvas.DrawOn(d.screen)
 	return nil
 }
 
-// drawBuildState draws a status box for an individual build within bounds
-func (d Dashboard) drawBuildState(build build, bounds rect) {
-	runeWriters := make([]RuneWriter, 0, 10)
-	attributeWriters := make([]AttributeWriter, 0, 10)
-
-	availableWidth := bounds.size.w - 2*textPadding
-	buildNameWithLengthRestriction, _ := elipsize(build.name, availableWidth)
+// screenWidget returns the widget for the whole screen, the MONITRON title
+// above a grid of the builds or the error if there are none. Errors that
+// come with builds, such as from one of several servers, the error from
+// reloading the config file and the last logged message are shown on the
+// bottom rows.
+func (d Dashboard) screenWidget() Widget {
+	content := d.buildGrid()
+	errorRows := []Widget{}
+	if d.err != nil && len(d.builds) == 0 {
+		content = NewPadding(2, 0, 0, 0,
+			NewLabel(fmt.Sprintf("Error: %s", d.err.Error()), d.theme.Error, AlignLeft))
+	} else if d.err != nil {
+		errorRows = append(errorRows,
+			NewLabel(fmt.Sprintf("Error: %s", d.err.Error()), d.theme.Error, AlignLeft))
+	}
+	// The title sits within the padding above the grid.
+	screen := NewStack(
+		NewPadding(1, 0, 0, 0, NewLabel("MONITRON 5000", d.theme.Title, AlignCentre)),
+		NewPadding(1, 0, 0, 0, content),
+	)
+	if d.configErr != nil {
+		// The builds are still shown using the previous settings.
+		errorRows = append(errorRows, NewLabel(
+			fmt.Sprintf("Config Error: %s", d.configErr.Error()), d.theme.Error, AlignLeft))
+	}
+	if d.logMessage != "" {
+		errorRows = append(errorRows, NewLabel(d.logMessage, d.theme.Error, AlignLeft))
+	}
+	if len(errorRows) == 0 {
+		return screen
+	}
+	return NewRows(append([]Widget{Expand(screen)}, errorRows...)...)
+}
 
-	runeWriters = append(runeWriters,
-		createBorderedBoxWriter(NewRect(0, 0, bounds.w, bounds.h)))
-	runeWriters = append(runeWriters,
-		createTextWriter(buildNameWithLengthRestriction, point{11, 1}))
+// changesOverTime returns true if the dashboard shows anything that
+// changes as time passes, the flashing borders of escalated builds or how
+// long builds have been failing, so it needs redrawing as the clock
+// ticks.
+func (d Dashboard) changesOverTime() bool {
+	if anyEscalated(d.builds) {
+		return true
+	}
+	if !d.layout.boxTemplate().shows("failing_since") {
+		return false
+	}
+	for _, build := range d.builds {
+		if !build.failingSince.IsZero() {
+			return true
+		}
+	}
+	return false
+}
 
-	if build.building {
-		runeWriters = append(runeWriters,
-			createTextWriter(buildingMessage, point{11, 2}))
-		runeWriters = append(runeWriters,
-			createTextWriter(build.acknowledger, point{20, 2}))
-	} else {
-		runeWriters = append(runeWriters,
-			createTextWriter(build.acknowledger, point{11, 2}))
+// buildGrid returns a grid of build boxes, escalated builds first.
+func (d Dashboard) buildGrid() Widget {
+	builds := escalatedFirst(d.builds)
+	boxes := make([]Widget, len(builds))
+	for i, build := range builds {
+		boxes[i] = d.buildBox(build)
 	}
+	// The boxes are tall enough for the swatch and every row of the template.
+	height := maxInt(len(d.layout.boxTemplate()), 3) + 2
+	return NewGrid(size{d.layout.BoxWidth, height}, d.layout.Padding, boxes...)
+}
 
-	attributeWriters = append(attributeWriters,
-		createBoxFillWriter(NewRect(2, 1, 7, 2),
-			build.buildState.BgColour()))
+// buildBox returns the status box for an individual build, a swatch in
+// the colour of its state beside the rows of details from the template.
+func (d Dashboard) buildBox(build build) Widget {
+	stateStyle := d.theme.stateStyle(build.buildState)
+	borderColour := d.theme.Border.Fg
+	if build.escalated && d.flash {
+		borderColour = stateStyle.Bg | AttrBold
+	}
 
-	for x := 0; x < bounds.w; x++ {
-		for y := 0; y < bounds.h; y++ {
-			char := ' '
-			bg := termbox.ColorBlack
-			fg := termbox.ColorWhite
+	swatch := NewBackground(stateStyle.Bg, NewRows(
+		NewSpacer(swatchWidth, 1),
+		NewLabel(string(build.buildState.glyph()), stateStyle, AlignCentre),
+	))
+	rows := []Widget{}
+	for _, row := range d.layout.boxTemplate() {
+		rows = append(rows, d.templateRow(build, row))
+	}
 
-			currentPoint := point{x, y}
-			for _, runeWriter := range runeWriters {
-				char = runeWriter(char, currentPoint)
-			}
-			for _, attrWriter := range attributeWriters {
-				fg, bg = attrWriter(fg, bg, currentPoint)
-			}
+	return NewBorder(borderColour, NewPadding(0, 1, 0, 1, NewColumns(
+		swatch,
+		NewSpacer(1, 1),
+		Expand(NewRows(rows...)),
+	)))
+}
 
-			d.cellDrawer.SetCell(x+bounds.x, y+bounds.y, char, fg, bg)
+// templateRow returns a row of the fields of build in row that aren't
+// empty, a space apart. Fields aligned to the centre or right share the
+// space left over in the row.
+func (d Dashboard) templateRow(build build, row TemplateRow) Widget {
+	labels := []Widget{}
+	for _, field := range row {
+		text := templateText(field.Name, build, d.clock.Now())
+		if text == "" {
+			continue
+		}
+		if len(labels) > 0 {
+			labels = append(labels, NewSpacer(1, 1))
+		}
+		style := d.theme.Text
+		if field.Name == "building" {
+			style = d.theme.Building
+		}
+		var label Widget = NewLabel(text, style, field.Align)
+		if field.Align != AlignLeft {
+			label = Expand(label)
 		}
+		labels = append(labels, label)
 	}
+	return NewColumns(labels...)
 }
 
-// termboxEventPoller runs as a separate go routine polling for termbox events
+// eventPoller runs as a separate go routine polling for input events
 // (which is a blocking call) and passing them back into the main runloop
-// allowing the selection between termbox events and network data being received
-func (d Dashboard) termboxEventPoller(eventChannel chan termbox.Event) {
+// allowing the selection between screen events and network data being received
+func (d Dashboard) eventPoller(eventChannel chan Event) {
 	for {
-		eventChannel <- termbox.PollEvent()
+		eventChannel <- d.events.PollEvent()
 	}
 }
@@ -3,12 +3,12 @@ package monitrondashboard
 // Tests for the Monitron dashboard
 
 import (
-	"bytes"
-	"github.com/nsf/termbox-go"
+	"errors"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"log"
 	"strings"
 	"testing"
+	"time"
 )
 
 var ellipsizeTests = []struct {
@@ -129,35 +129,35 @@ func TestBorderBoxWriterDrawsBox(t *testing.T) {
 }
 
 func TestBoxFillWriterFillsBox(t *testing.T) {
-	bfw := createBoxFillWriter(NewRect(1, 1, 5, 5), termbox.ColorCyan)
+	bfw := createBoxFillWriter(NewRect(1, 1, 5, 5), ColourCyan)
 
 	// test left/right margin
 	for y := 0; y < 7; y++ {
-		_, outputAttribute := bfw(termbox.ColorWhite, termbox.ColorBlack, point{0, y})
-		assert.Equal(t, termbox.ColorBlack, outputAttribute,
+		_, outputAttribute := bfw(ColourWhite, ColourBlack, point{0, y})
+		assert.Equal(t, ColourBlack, outputAttribute,
 			"Expected (%d,%d) to not have its colour changed", 0, y)
 
-		_, outputAttribute = bfw(termbox.ColorWhite, termbox.ColorBlack, point{7, y})
-		assert.Equal(t, termbox.ColorBlack, outputAttribute,
+		_, outputAttribute = bfw(ColourWhite, ColourBlack, point{7, y})
+		assert.Equal(t, ColourBlack, outputAttribute,
 			"Expected (%d,%d) to not have its colour changed", 7, y)
 	}
 
 	// test top/bottom margin
 	for x := 0; x < 7; x++ {
-		_, outputAttribute := bfw(termbox.ColorWhite, termbox.ColorBlack, point{x, 0})
-		assert.Equal(t, termbox.ColorBlack, outputAttribute,
+		_, outputAttribute := bfw(ColourWhite, ColourBlack, point{x, 0})
+		assert.Equal(t, ColourBlack, outputAttribute,
 			"Expected (%d,%d) to not have its colour changed", x, 0)
 
-		_, outputAttribute = bfw(termbox.ColorWhite, termbox.ColorBlack, point{x, 7})
-		assert.Equal(t, termbox.ColorBlack, outputAttribute,
+		_, outputAttribute = bfw(ColourWhite, ColourBlack, point{x, 7})
+		assert.Equal(t, ColourBlack, outputAttribute,
 			"Expected (%d,%d) to not have its colour changed", x, 7)
 	}
 
 	// test filled in square
 	for x := 1; x <= 6; x++ {
 		for y := 1; y <= 6; y++ {
-			_, outputAttribute := bfw(termbox.ColorWhite, termbox.ColorBlack, point{x, y})
-			assert.Equal(t, termbox.ColorCyan, outputAttribute,
+			_, outputAttribute := bfw(ColourWhite, ColourBlack, point{x, y})
+			assert.Equal(t, ColourCyan, outputAttribute,
 				"Expected (%d,%d) to have its colour changed to form the box", x, y)
 		}
 	}
@@ -165,12 +165,12 @@ func TestBoxFillWriterFillsBox(t *testing.T) {
 }
 
 func TestBoxFillWriterDoesNotAlterForeground(t *testing.T) {
-	bfw := createBoxFillWriter(NewRect(1, 1, 5, 5), termbox.ColorCyan)
+	bfw := createBoxFillWriter(NewRect(1, 1, 5, 5), ColourCyan)
 
 	for x := 0; x < 10; x++ {
 		for y := 0; y < 10; y++ {
-			fgAttribute, _ := bfw(termbox.ColorWhite, termbox.ColorBlack, point{x, y})
-			assert.Equal(t, termbox.ColorWhite, fgAttribute,
+			fgAttribute, _ := bfw(ColourWhite, ColourBlack, point{x, y})
+			assert.Equal(t, ColourWhite, fgAttribute,
 				"Expected (%d,%d) to not have changed foreground colour.", x, y)
 		}
 	}
@@ -240,106 +240,418 @@ func TestLayoutGridErrorsWhenNotEnoughSpace(t *testing.T) {
 	}
 }
 
-// memoryCellWriter is a CellWriter struct that can be used
-// to assert widgets being drawn correctly on the 'screen'
-type memoryCellWriter struct {
-	cells [][]memoryCell
-	maxX  int
-	maxY  int
-	mock.Mock
+// assertCellColours asserts the cell at x, y of screen was drawn with
+// fg and bg.
+func assertCellColours(t *testing.T, screen *VirtualScreen, x, y int, fg, bg Colour, fgText, bgText string) {
+	assert.Equal(t, fg, screen.Cell(x, y).Fg,
+		"Cell at %d,%d should have %s", x, y, fgText)
+	assert.Equal(t, bg, screen.Cell(x, y).Bg,
+		"Cell at %d,%d should have %s", x, y, bgText)
 }
 
-// memoryCell represents a drawn cell, holding its rune and attributes.
-type memoryCell struct {
-	char rune
-	fg   termbox.Attribute
-	bg   termbox.Attribute
+func TestDrawingABuild(t *testing.T) {
+	expectedString := `
+ ┏━━━━━━━━━━━━━━━━━━━━━━━━━━━━┓
+ ┃          Test Build        ┃
+ ┃    ✗     Building Dave     ┃
+ ┗━━━━━━━━━━━━━━━━━━━━━━━━━━━━┛`
+
+	screen := NewVirtualScreen(31, 4)
+	dashboard := NewDashboard(nil, screen)
+	testBuild := build{
+		name:         "Test Build",
+		buildState:   BuildStateFailed,
+		building:     true,
+		acknowledger: "Dave",
+	}
+
+	dashboard.drawWidget(dashboard.buildBox(testBuild), NewRect(1, 0, 30, 4))
+	output := strings.Trim(screen.Text(), "\n")
+	expectedString = strings.Trim(expectedString, "\n")
+	assert.Equal(t, expectedString, output, "Compare: \n%s\nvs.\n%s", expectedString, output)
 }
 
-func NewMemoryCellWriter() memoryCellWriter {
-	return memoryCellWriter{
-		cells: [][]memoryCell{},
-		maxX:  0,
-		maxY:  0,
+func TestDrawingABuildKeepsALongNameInsideItsBox(t *testing.T) {
+	expectedString := `
+┏━━━━━━━━━━━━━━━━━━━━━━━━━━━━┓
+┃          a-build-with-a... ┃
+┃    ✓                       ┃
+┃                            ┃
+┗━━━━━━━━━━━━━━━━━━━━━━━━━━━━┛`
+
+	screen := NewVirtualScreen(30, 5)
+	dashboard := NewDashboard(nil, screen)
+	testBuild := build{
+		name:       "a-build-with-a-name-too-long-for-its-box",
+		buildState: BuildStatePassed,
 	}
+
+	dashboard.drawWidget(dashboard.buildBox(testBuild), NewRect(0, 0, 30, 5))
+	output := strings.Trim(screen.Text(), "\n")
+	expectedString = strings.Trim(expectedString, "\n")
+	assert.Equal(t, expectedString, output, "Compare: \n%s\nvs.\n%s", expectedString, output)
+}
+
+func TestBuildsWithAnErrorHaveTheErrorDrawnOnTheBottomRow(t *testing.T) {
+	screen := NewVirtualScreen(36, 10)
+	dashboard := NewDashboard(nil, screen)
+	dashboard.logMessage = "Error running notify command"
+
+	dashboard.updateBuilds(BuildUpdate{
+		builds: []build{{name: "Test Build", buildState: BuildStatePassed}},
+		err:    errors.New("second: Error Connecting"),
+	})
+
+	rows := strings.Split(screen.Text(), "\n")
+	assert.Contains(t, rows[3], "Test Build")
+	assert.Equal(t, "Error: second: Error Connecting", rows[8])
+	assert.Equal(t, "Error running notify command", rows[9])
+}
+
+func TestConfigErrorsAreDrawnAboveTheLoggedMessage(t *testing.T) {
+	screen := NewVirtualScreen(40, 9)
+	dashboard := NewDashboard(nil, screen)
+	dashboard.configErr = errors.New("line 2: unknown sort order")
+	dashboard.logMessage = "Error running notify command"
+
+	dashboard.redraw()
+
+	rows := strings.Split(screen.Text(), "\n")
+	assert.Equal(t, "Config Error: line 2: unknown sort order", rows[7])
+	assert.Equal(t, "Error running notify command", rows[8])
+}
+
+func TestAnErrorWithoutBuildsIsNotDrawnOnTheBottomRows(t *testing.T) {
+	screen := NewVirtualScreen(36, 9)
+	dashboard := NewDashboard(nil, screen)
+
+	dashboard.updateBuilds(BuildUpdate{builds: []build{}, err: errors.New("Error Connecting")})
+
+	rows := strings.Split(screen.Text(), "\n")
+	assert.Equal(t, "Error: Error Connecting", rows[3])
+	assert.Equal(t, "", rows[8])
 }
 
-func (m memoryCellWriter) Flush() {
-	m.Called()
+func TestLoggedMessagesAreDrawnOnTheBottomRow(t *testing.T) {
+	screen := NewVirtualScreen(36, 9)
+	dashboard := NewDashboard(nil, screen)
+
+	logger := log.New(dashboard.LogWriter(), "", 0)
+	logger.Printf("Error running notify command: exit status 1")
+	dashboard.logMessage = <-dashboard.logChannel
+	dashboard.redraw()
+
+	rows := strings.Split(screen.Text(), "\n")
+	assert.Equal(t, "Error running notify command: exi...", rows[8])
+}
+
+func TestUpdatingTheBuildsClearsWhatWasDrawnBefore(t *testing.T) {
+	screen := NewVirtualScreen(36, 14)
+	dashboard := NewDashboard(nil, screen)
+	dashboard.updateBuilds(BuildUpdate{builds: []build{
+		{name: "First Build", buildState: BuildStatePassed},
+		{name: "Gone Build", buildState: BuildStateFailed},
+	}})
+
+	dashboard.updateBuilds(BuildUpdate{builds: []build{
+		{name: "First Build", buildState: BuildStatePassed},
+	}})
+
+	assert.Contains(t, screen.Text(), "First Build")
+	assert.NotContains(t, screen.Text(), "Gone Build")
+}
+
+func TestDrawingABuildColoursItsStateGlyph(t *testing.T) {
+	screen := NewVirtualScreen(40, 10)
+	dashboard := NewDashboard(nil, screen)
+
+	for _, state := range buildStates {
+		dashboard.drawWidget(dashboard.buildBox(build{name: "Test Build", buildState: state}),
+			NewRect(0, 0, 30, 5))
+		style := DefaultTheme.stateStyle(state)
+		assert.Equal(t, state.glyph(), screen.Cell(5, 2).Ch, "%s", state)
+		assertCellColours(t, screen, 5, 2, style.Fg, style.Bg,
+			"the state's text colour", "the state's colour")
+	}
 }
 
-func (m *memoryCellWriter) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
-	if m.maxX < x {
-		m.maxX = x
+func TestDrawingAnEscalatedBuildFlashesItsBorder(t *testing.T) {
+	screen := NewVirtualScreen(40, 10)
+	dashboard := NewDashboard(nil, screen)
+	testBuild := build{
+		name:       "Test Build",
+		buildState: BuildStateFailed,
+		escalated:  true,
 	}
 
-	if m.maxY < y {
-		m.maxY = y
+	dashboard.flash = true
+	dashboard.drawWidget(dashboard.buildBox(testBuild), NewRect(0, 0, 30, 4))
+	assertCellColours(t, screen, 0, 0, ColourRed|AttrBold,
+		ColourBlack, "a red border", "a black background")
+	assertCellColours(t, screen, 15, 3, ColourRed|AttrBold,
+		ColourBlack, "a red border", "a black background")
+	assertCellColours(t, screen, 15, 1, ColourWhite,
+		ColourBlack, "white text", "a black background")
+
+	dashboard.flash = false
+	dashboard.drawWidget(dashboard.buildBox(testBuild), NewRect(0, 0, 30, 4))
+	assertCellColours(t, screen, 0, 0, ColourWhite,
+		ColourBlack, "a white border", "a black background")
+}
+
+var keyTests = []struct {
+	key     Key
+	event   Event
+	matches bool
+}{
+	{"q", Event{Type: EventKey, Key: KeyRune, Ch: 'q'}, true},
+	{"q", Event{Type: EventKey, Key: KeyRune, Ch: 'x'}, false},
+	{"space", Event{Type: EventKey, Key: KeySpace}, true},
+	{"space", Event{Type: EventKey, Key: KeyRune, Ch: 's'}, false},
+	{"n", Event{Type: EventKey, Key: KeySpace}, false},
+	{"q", Event{Type: EventKey, Key: KeyEsc}, false},
+}
+
+func TestKeyMatches(t *testing.T) {
+	for _, test := range keyTests {
+		assert.Equal(t, test.matches, test.key.matches(test.event), "%q %+v",
+			test.key, test.event)
 	}
+}
 
-	if len(m.cells) < x+1 {
-		cells := make([][]memoryCell, x+1)
-		copy(cells, m.cells)
-		m.cells = cells[0 : x+1]
+// frameRecorder is a VirtualScreen recording the text of each frame
+// flushed that differs from the one before.
+type frameRecorder struct {
+	*VirtualScreen
+	frames  []string
+	flushes int
+	closed  bool
+}
+
+func (f *frameRecorder) Flush() {
+	f.flushes++
+	text := f.Text()
+	if len(f.frames) == 0 || f.frames[len(f.frames)-1] != text {
+		f.frames = append(f.frames, text)
 	}
+}
 
-	cellColumn := m.cells[x]
-	if cellColumn == nil {
-		m.cells[x] = make([]memoryCell, y+1)
-	} else if len(m.cells[x]) < y+1 {
-		cells := make([]memoryCell, y+1)
-		copy(cells, m.cells[x])
-		m.cells[x] = cells[0 : y+1]
+func (f *frameRecorder) Close() {
+	f.closed = true
+}
+
+// runDashboard runs dashboard with its input events from script,
+// returning the error it exits with, failing the test if it doesn't exit.
+func runDashboard(t *testing.T, dashboard Dashboard, script ...ScriptStep) error {
+	dashboard.SetEventSource(NewScriptedEvents(script...))
+	done := make(chan error)
+	go func() {
+		done <- dashboard.Run()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("The dashboard didn't exit")
 	}
-	m.cells[x][y] = memoryCell{ch, fg, bg}
-}
-
-// ScreenRepresentation returns a string representing the layout of the screen.
-// Each line is terminated with |\n this representation can be asserted against
-// to test drawing functions.
-func (m memoryCellWriter) ScreenPresentation() string {
-	var buffer bytes.Buffer
-	for y := 0; y <= m.maxY; y++ {
-		for x := 0; x <= m.maxX; x++ {
-			if len(m.cells[x]) < y+1 {
-				// no character stored here
-				buffer.WriteRune(' ')
-			} else {
-				buffer.WriteRune(m.cells[x][y].char)
-			}
-		}
-		buffer.WriteString("|\n")
+	return nil
+}
+
+func TestRunningTheDashboardDrawsAFrameForEachEvent(t *testing.T) {
+	screen := &frameRecorder{VirtualScreen: NewVirtualScreen(40, 4)}
+	fetcher := stubBuildFetcher{make(chan BuildUpdate)}
+	dashboard := NewDashboard(fetcher, screen)
+
+	runDashboard(t, dashboard,
+		SendResize(36, 8),
+		Do(func() {
+			fetcher.buildChannel <- BuildUpdate{builds: []build{
+				{name: "Test Build", buildState: BuildStatePassed},
+			}}
+		}),
+		SendKey('q'),
+	)
+
+	assert.Equal(t, []string{
+		"\n" +
+			"             MONITRON 5000\n" +
+			"\n" +
+			"\n",
+		"\n" +
+			"           MONITRON 5000\n" +
+			"\n" +
+			"\n" +
+			"\n" +
+			"\n" +
+			"\n" +
+			"\n",
+		"\n" +
+			"           MONITRON 5000\n" +
+			" ┏━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━┓\n" +
+			" ┃          Test Build            ┃\n" +
+			" ┃    ✓                           ┃\n" +
+			" ┃                                ┃\n" +
+			" ┗━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━┛\n" +
+			"\n",
+	}, screen.frames)
+	assert.True(t, screen.closed, "The screen should be closed on exit")
+}
+
+var quitTests = []struct {
+	event  Event
+	err    error
+	reason string
+}{
+	{Event{Type: EventKey, Key: KeyRune, Ch: 'q'}, nil, "the quit key"},
+	{Event{Type: EventKey, Key: KeyEsc}, nil, "escape"},
+	{Event{Type: EventError, Err: errors.New("input closed")}, errors.New("input closed"),
+		"an input error"},
+}
+
+func TestRunningTheDashboardExits(t *testing.T) {
+	for _, test := range quitTests {
+		screen := &frameRecorder{VirtualScreen: NewVirtualScreen(40, 4)}
+		dashboard := NewDashboard(stubBuildFetcher{make(chan BuildUpdate)}, screen)
+
+		err := runDashboard(t, dashboard, SendKey('n'), SendEvent(test.event))
+
+		assert.Equal(t, test.err, err, "The dashboard should exit with the error of %s",
+			test.reason)
+		assert.True(t, screen.closed, "The screen should be closed after %s", test.reason)
 	}
+}
 
-	return buffer.String()
+func TestRunningTheDashboardUsesTheQuitKeyFromItsSettings(t *testing.T) {
+	screen := &frameRecorder{VirtualScreen: NewVirtualScreen(40, 4)}
+	fetcher := stubBuildFetcher{make(chan BuildUpdate)}
+	dashboard := NewDashboard(fetcher, screen)
+	settings := DefaultSettings()
+	settings.Keys.Quit = "x"
+	dashboard.ApplySettings(settings)
+
+	runDashboard(t, dashboard,
+		SendKey('q'),
+		Do(func() {
+			fetcher.buildChannel <- BuildUpdate{err: errors.New("still running")}
+		}),
+		SendKey('x'),
+	)
+
+	assert.Equal(t, "Error: still running", strings.Split(screen.Text(), "\n")[3])
 }
 
-func (m memoryCellWriter) AssertCellAttributes(t *testing.T, x, y int, fg, bg termbox.Attribute, fgAttrText, bgAttrText string) {
-	assert.Equal(t, fg, m.cells[x][y].fg,
-		"Cell at %d,%d should have %s", x, y, fgAttrText)
-	assert.Equal(t, bg, m.cells[x][y].bg,
-		"Cell at %d,%d should have %s", x, y, bgAttrText)
+func TestRunningTheDashboardFlashesEscalatedBuildsAsItTicks(t *testing.T) {
+	screen := &frameRecorder{VirtualScreen: NewVirtualScreen(36, 8)}
+	fetcher := stubBuildFetcher{make(chan BuildUpdate)}
+	clock := NewFakeClock(time.Unix(1425590828, 0))
+	dashboard := NewDashboard(fetcher, screen)
+	dashboard.SetClock(clock)
+
+	runDashboard(t, dashboard,
+		Do(func() {
+			fetcher.buildChannel <- BuildUpdate{builds: []build{
+				{name: "Test Build", buildState: BuildStateFailed, escalated: true},
+			}}
+		}),
+		Do(func() { clock.Advance(tickInterval) }),
+		SendKey('q'),
+	)
+
+	assertCellColours(t, screen.VirtualScreen, 1, 2, ColourRed|AttrBold, ColourBlack,
+		"a red border", "a black background")
+}
 
+var tickTests = []struct {
+	build    build
+	template BoxTemplate
+	redraws  bool
+	reason   string
+}{
+	{build{name: "Test Build", buildState: BuildStatePassed}, DefaultBoxTemplate, false,
+		"a passing build"},
+	{build{name: "Test Build", buildState: BuildStateFailed, escalated: true},
+		DefaultBoxTemplate, true, "an escalated build"},
+	{build{name: "Test Build", buildState: BuildStateFailed,
+		failingSince: time.Unix(1425590828, 0)}, DefaultBoxTemplate, false,
+		"a failing build without its failing time shown"},
+	{build{name: "Test Build", buildState: BuildStateFailed,
+		failingSince: time.Unix(1425590828, 0)},
+		BoxTemplate{{{Name: "name"}}, {{Name: "failing_since"}}}, true,
+		"a failing build with its failing time shown"},
 }
 
-func TestDrawingABuild(t *testing.T) {
-	expectedString := `
- ┏━━━━━━━━━━━━━━━━━━━━━━━━━━━━┓|
- ┃          Test Build        ┃|
- ┃          Building Dave     ┃|
- ┗━━━━━━━━━━━━━━━━━━━━━━━━━━━━┛|`
+func TestRunningTheDashboardOnlyRedrawsAsItTicksIfTimeChangesWhatIsShown(t *testing.T) {
+	for _, test := range tickTests {
+		// flushes returns how many times the dashboard flushes the screen
+		// showing the build, ticking ticks times.
+		flushes := func(ticks int) int {
+			screen := &frameRecorder{VirtualScreen: NewVirtualScreen(36, 8)}
+			fetcher := stubBuildFetcher{make(chan BuildUpdate)}
+			clock := NewFakeClock(time.Unix(1425590828, 0))
+			dashboard := NewDashboard(fetcher, screen)
+			dashboard.SetClock(clock)
+			settings := DefaultSettings()
+			settings.Layout.Template = test.template
+			dashboard.ApplySettings(settings)
+
+			runDashboard(t, dashboard,
+				Do(func() { fetcher.buildChannel <- BuildUpdate{builds: []build{test.build}} }),
+				Do(func() { clock.Advance(time.Duration(ticks) * tickInterval) }),
+				SendKey('q'),
+			)
+			return screen.flushes
+		}
 
-	cw := NewMemoryCellWriter()
-	dashboard := NewDashboard(nil, &cw)
+		assert.Equal(t, test.redraws, flushes(2) > flushes(0),
+			"Whether ticking redraws %s", test.reason)
+	}
+}
+
+func TestDrawingABuildWithATemplate(t *testing.T) {
+	expectedString := `
+ ┏━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━┓
+ ┃          Test Build               ┃
+ ┃    ✗     failed     failing 2h13m ┃
+ ┃          2 failures               ┃
+ ┃          jenkins.example.com      ┃
+ ┗━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━┛`
+
+	screen := NewVirtualScreen(38, 6)
+	now := time.Unix(1425590828, 0)
+	dashboard := NewDashboard(nil, screen)
+	dashboard.SetClock(NewFakeClock(now))
+	settings := DefaultSettings()
+	settings.Colours = ColourMode256
+	settings.Layout.Template = BoxTemplate{
+		{{Name: "name"}},
+		{{Name: "state"}, {Name: "acknowledger"}, {Name: "failing_since", Align: AlignRight}},
+		{{Name: "failures"}},
+		{{Name: "url_host"}},
+	}
+	dashboard.ApplySettings(settings)
 	testBuild := build{
 		name:         "Test Build",
 		buildState:   BuildStateFailed,
-		building:     true,
-		acknowledger: "Dave",
+		failingSince: now.Add(-(2*time.Hour + 13*time.Minute)),
+		failures:     2,
+		url:          "https://jenkins.example.com/job/Test%20Build/",
 	}
 
-	dashboard.drawBuildState(testBuild, NewRect(1, 0, 30, 4))
-	output := strings.Trim(cw.ScreenPresentation(), "\n")
+	dashboard.drawWidget(dashboard.buildBox(testBuild), NewRect(1, 0, 37, 6))
+	output := strings.Trim(screen.Text(), "\n")
 	expectedString = strings.Trim(expectedString, "\n")
 	assert.Equal(t, expectedString, output, "Compare: \n%s\nvs.\n%s", expectedString, output)
 }
+
+func TestBuildBoxesAreTallEnoughForTheirTemplate(t *testing.T) {
+	dashboard := NewDashboard(nil, NewVirtualScreen(40, 10))
+	dashboard.builds = []build{{name: "Test Build"}}
+	assert.Equal(t, size{32, 7}, dashboard.buildGrid().Measure(),
+		"A box and its padding should be 5 high")
+
+	settings := DefaultSettings()
+	settings.Layout.Template = BoxTemplate{{}, {}, {}, {}}
+	dashboard.ApplySettings(settings)
+	assert.Equal(t, size{32, 8}, dashboard.buildGrid().Measure(),
+		"A box and its padding should be 6 high")
+}
@@ -0,0 +1,139 @@
+package monitrondashboard
+
+// Build diffing for the monitron dashboard.
+// Here you'll find code for comparing successive BuildUpdates to find
+// the builds that have changed between them.
+
+// buildChangeKind is an int type describing how a build differs
+// between two build lists.
+type buildChangeKind int
+
+const (
+	buildAdded buildChangeKind = iota
+	buildRemoved
+	buildChanged
+)
+
+// buildChange describes the difference in a single build between two
+// build lists, previous is empty for an added build and current is
+// empty for a removed one.
+type buildChange struct {
+	kind     buildChangeKind
+	previous build
+	current  build
+}
+
+// name returns the name of the build that changed.
+func (c buildChange) name() string {
+	if c.kind == buildRemoved {
+		return c.previous.name
+	}
+	return c.current.name
+}
+
+// diffBuilds returns the changes required to get from the previous build
+// list to the current one, in the order of current followed by any
+// builds that have been removed.
+func diffBuilds(previous, current []build) []buildChange {
+	previousByName := make(map[string]build, len(previous))
+	for _, build := range previous {
+		previousByName[build.name] = build
+	}
+	currentByName := make(map[string]bool, len(current))
+
+	changes := []buildChange{}
+	for _, currentBuild := range current {
+		currentByName[currentBuild.name] = true
+		previousBuild, ok := previousByName[currentBuild.name]
+		if !ok {
+			changes = append(changes,
+				buildChange{buildAdded, build{}, currentBuild})
+		} else if buildsDiffer(previousBuild, currentBuild) {
+			changes = append(changes,
+				buildChange{buildChanged, previousBuild, currentBuild})
+		}
+	}
+	for _, previousBuild := range previous {
+		if !currentByName[previousBuild.name] {
+			changes = append(changes,
+				buildChange{buildRemoved, previousBuild, build{}})
+		}
+	}
+	return changes
+}
+
+// withMissingBuilds returns current followed by the builds in previous
+// that current doesn't have. An update with an error can still hold the
+// builds of the servers that are working, and the builds it is missing
+// haven't gone, their server just can't be reached.
+func withMissingBuilds(previous, current []build) []build {
+	currentByName := make(map[string]bool, len(current))
+	for _, build := range current {
+		currentByName[build.name] = true
+	}
+	builds := append([]build{}, current...)
+	for _, build := range previous {
+		if !currentByName[build.name] {
+			builds = append(builds, build)
+		}
+	}
+	return builds
+}
+
+// buildsDiffer returns true if previous and current differ in more than
+// their number of failures or url, which change as a failing build keeps
+// failing without anything worth reporting.
+func buildsDiffer(previous, current build) bool {
+	previous.failures, previous.url = current.failures, current.url
+	return previous != current
+}
+
+// buildTransitionKind is an int type for the transitions between build
+// states that people want to be told about.
+type buildTransitionKind int
+
+const (
+	buildTransitionFailed buildTransitionKind = iota
+	buildTransitionRecovered
+)
+
+// String returns the lower case name of the transition.
+func (k buildTransitionKind) String() string {
+	if k == buildTransitionFailed {
+		return "failed"
+	}
+	return "recovered"
+}
+
+// buildTransition is a change in which a build started failing or
+// recovered.
+type buildTransition struct {
+	kind   buildTransitionKind
+	change buildChange
+}
+
+// buildTransitions returns the changes in which a build started failing,
+// including new builds that are failing, or went from failing or
+// acknowledged back to passing.
+func buildTransitions(changes []buildChange) []buildTransition {
+	transitions := []buildTransition{}
+	for _, change := range changes {
+		switch {
+		case change.kind == buildRemoved:
+			continue
+		case change.current.buildState == BuildStateFailed &&
+			(change.kind == buildAdded ||
+				change.previous.buildState != BuildStateFailed &&
+					change.previous.buildState != BuildStateAcknowledged):
+			transitions = append(transitions,
+				buildTransition{buildTransitionFailed, change})
+		case change.kind == buildChanged &&
+			change.current.buildState == BuildStatePassed &&
+			(change.previous.buildState == BuildStateFailed ||
+				change.previous.buildState == BuildStateAcknowledged):
+			transitions = append(transitions,
+				buildTransition{buildTransitionRecovered, change})
+		}
+	}
+	return transitions
+}
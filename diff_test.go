@@ -0,0 +1,75 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiffBuildsFindsNoChangesInIdenticalLists(t *testing.T) {
+	builds := []build{
+		{name: "Build", buildState: BuildStatePassed},
+		{name: "Failing Build", buildState: BuildStateFailed},
+	}
+
+	assert.Empty(t, diffBuilds(builds, builds))
+}
+
+func TestDiffBuildsIgnoresChangesInTheNumberOfFailures(t *testing.T) {
+	previous := []build{{name: "Failing Build", buildState: BuildStateFailed, failures: 1}}
+	current := []build{{name: "Failing Build", buildState: BuildStateFailed, failures: 2,
+		url: "http://ci/job/failing-build/2/"}}
+
+	assert.Empty(t, diffBuilds(previous, current))
+}
+
+func TestDiffBuildsFindsAddedChangedAndRemovedBuilds(t *testing.T) {
+	previous := []build{
+		{name: "Build", buildState: BuildStatePassed},
+		{name: "Failing Build", buildState: BuildStateFailed},
+		{name: "Old Build", buildState: BuildStatePassed},
+	}
+	current := []build{
+		{name: "Build", buildState: BuildStatePassed},
+		{name: "Failing Build", buildState: BuildStateAcknowledged, acknowledger: "Dave"},
+		{name: "New Build", buildState: BuildStatePassed, building: true},
+	}
+
+	changes := diffBuilds(previous, current)
+
+	assert.Equal(t, []buildChange{
+		{buildChanged, previous[1], current[1]},
+		{buildAdded, build{}, current[2]},
+		{buildRemoved, previous[2], build{}},
+	}, changes)
+	assert.Equal(t, "Failing Build", changes[0].name())
+	assert.Equal(t, "New Build", changes[1].name())
+	assert.Equal(t, "Old Build", changes[2].name())
+}
+
+func TestBuildTransitionsFindsFailuresAndRecoveries(t *testing.T) {
+	previous := []build{
+		{name: "Breaking", buildState: BuildStatePassed},
+		{name: "Recovering", buildState: BuildStateAcknowledged, acknowledger: "Dave"},
+		{name: "Unacknowledged", buildState: BuildStateAcknowledged, acknowledger: "Dave"},
+		{name: "Acknowledged", buildState: BuildStateFailed},
+		{name: "Removed", buildState: BuildStateFailed},
+	}
+	current := []build{
+		{name: "Breaking", buildState: BuildStateFailed},
+		{name: "Recovering", buildState: BuildStatePassed},
+		{name: "Unacknowledged", buildState: BuildStateFailed},
+		{name: "Acknowledged", buildState: BuildStateAcknowledged, acknowledger: "Dave"},
+		{name: "New", buildState: BuildStateFailed},
+	}
+
+	transitions := buildTransitions(diffBuilds(previous, current))
+
+	if assert.Len(t, transitions, 3) {
+		assert.Equal(t, buildTransitionFailed, transitions[0].kind)
+		assert.Equal(t, "Breaking", transitions[0].change.name())
+		assert.Equal(t, buildTransitionRecovered, transitions[1].kind)
+		assert.Equal(t, "Recovering", transitions[1].change.name())
+		assert.Equal(t, buildTransitionFailed, transitions[2].kind)
+		assert.Equal(t, "New", transitions[2].change.name())
+	}
+}
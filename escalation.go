@@ -0,0 +1,212 @@
+package monitrondashboard
+
+// Escalation for the monitron dashboard.
+// Here you'll find code for spotting builds that have been failing
+// without being acknowledged for too long, so they can be made to stand
+// out on the dashboard and an escalation hook run.
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// escalationCheckInterval is how often builds are checked for escalation
+// between updates.
+const escalationCheckInterval = 10 * time.Second
+
+// Escalator is a BuildFetcher that wraps another BuildFetcher, marking
+// builds that have been failing without an acknowledger for longer than
+// a threshold as escalated and running a command for each one.
+type Escalator struct {
+	fetcher      BuildFetcher
+	buildChannel chan BuildUpdate
+	threshold    time.Duration
+	command      string
+	runCommand   func(command *exec.Cmd) error
+	clock        Clock
+
+	failingSince map[string]time.Time
+	escalated    map[string]bool
+	running      sync.WaitGroup
+}
+
+// NewEscalator creates an Escalator escalating builds from fetcher that
+// have been failing for threshold, running command with sh -c for each,
+// if it isn't empty.
+func NewEscalator(fetcher BuildFetcher, threshold time.Duration, command string) *Escalator {
+	return newEscalator(fetcher, threshold, command, SystemClock)
+}
+
+// newEscalator creates and starts an Escalator telling the time with
+// clock.
+func newEscalator(fetcher BuildFetcher, threshold time.Duration, command string,
+	clock Clock) *Escalator {
+	escalator := &Escalator{
+		fetcher:      fetcher,
+		buildChannel: make(chan BuildUpdate),
+		threshold:    threshold,
+		command:      command,
+		runCommand:   (*exec.Cmd).Run,
+		clock:        clock,
+		failingSince: map[string]time.Time{},
+		escalated:    map[string]bool{},
+	}
+	go escalator.forwardBuilds()
+	return escalator
+}
+
+func (e *Escalator) BuildChannel() chan BuildUpdate {
+	return e.buildChannel
+}
+
+// forwardBuilds passes on every update from the wrapped fetcher with
+// escalated builds marked, and resends the latest update if a build
+// becomes escalated between updates.
+func (e *Escalator) forwardBuilds() {
+	ticker := e.clock.NewTicker(escalationCheckInterval)
+	defer ticker.Stop()
+
+	var latest BuildUpdate
+	buildChannel := e.fetcher.BuildChannel()
+	for {
+		select {
+		case buildUpdate, ok := <-buildChannel:
+			if !ok {
+				e.running.Wait()
+				close(e.buildChannel)
+				return
+			}
+			latest = buildUpdate
+			now := e.clock.Now()
+			buildUpdate, _, newlyEscalated := e.escalate(buildUpdate, now)
+			e.runEscalationCommands(newlyEscalated, now)
+			e.buildChannel <- buildUpdate
+		case now := <-ticker.C():
+			if latest.builds == nil {
+				continue
+			}
+			buildUpdate, changed, newlyEscalated := e.escalate(latest, now)
+			e.runEscalationCommands(newlyEscalated, now)
+			if changed {
+				e.buildChannel <- buildUpdate
+			}
+		}
+	}
+}
+
+// escalate returns a copy of buildUpdate with builds that have been
+// failing for longer than the threshold at now marked as escalated,
+// whether that changed any builds and the builds that have only just
+// been escalated. Builds that Monitron doesn't give a failing since time
+// for are timed from when they were first seen failing. Builds missing
+// from an update with an error keep their timings until their server is
+// back.
+func (e *Escalator) escalate(buildUpdate BuildUpdate, now time.Time) (BuildUpdate, bool, []build) {
+	if buildUpdate.err != nil && len(buildUpdate.builds) == 0 {
+		return buildUpdate, false, []build{}
+	}
+
+	changed := false
+	newlyEscalated := []build{}
+	failingSince := map[string]time.Time{}
+	escalated := map[string]bool{}
+	builds := make([]build, len(buildUpdate.builds))
+	for i, build := range buildUpdate.builds {
+		if build.buildState == BuildStateFailed && build.acknowledger == "" {
+			since := build.failingSince
+			if since.IsZero() {
+				since = e.failingSince[build.name]
+				if since.IsZero() {
+					since = now
+				}
+			}
+			failingSince[build.name] = since
+
+			if now.Sub(since) >= e.threshold {
+				build.escalated = true
+				escalated[build.name] = true
+				if !e.escalated[build.name] {
+					changed = true
+					newlyEscalated = append(newlyEscalated, build)
+				}
+			}
+		}
+		if !build.escalated && e.escalated[build.name] {
+			changed = true
+		}
+		builds[i] = build
+	}
+
+	if buildUpdate.err != nil {
+		for name, since := range e.failingSince {
+			if _, ok := failingSince[name]; !ok {
+				failingSince[name] = since
+				escalated[name] = e.escalated[name]
+			}
+		}
+	}
+	e.failingSince = failingSince
+	e.escalated = escalated
+	return BuildUpdate{builds: builds, err: buildUpdate.err}, changed, newlyEscalated
+}
+
+// runEscalationCommands runs the escalation command in the background
+// for each of builds.
+func (e *Escalator) runEscalationCommands(builds []build, now time.Time) {
+	if e.command == "" {
+		return
+	}
+	for _, escalatedBuild := range builds {
+		e.running.Add(1)
+		go func(build build, since time.Time) {
+			defer e.running.Done()
+			e.runEscalationCommand(build, since, now)
+		}(escalatedBuild, e.failingSince[escalatedBuild.name])
+	}
+}
+
+// runEscalationCommand runs the escalation command for a build that has
+// been failing since since.
+func (e *Escalator) runEscalationCommand(build build, since, now time.Time) {
+	command := exec.Command("sh", "-c", e.command)
+	command.Env = append(os.Environ(),
+		"MD_BUILD_NAME="+build.name,
+		"MD_BUILD_STATE="+build.buildState.String(),
+		"MD_FAILING_SINCE="+since.Format(time.RFC3339),
+		fmt.Sprintf("MD_FAILING_SECONDS=%d", int64(now.Sub(since)/time.Second)),
+	)
+	if err := e.runCommand(command); err != nil {
+		log.Printf("Error running escalation command: %s", err)
+	}
+}
+
+// escalatedFirst returns builds with the escalated builds moved to the
+// front, otherwise keeping their order.
+func escalatedFirst(builds []build) []build {
+	ordered := make([]build, 0, len(builds))
+	for _, build := range builds {
+		if build.escalated {
+			ordered = append(ordered, build)
+		}
+	}
+	for _, build := range builds {
+		if !build.escalated {
+			ordered = append(ordered, build)
+		}
+	}
+	return ordered
+}
+
+// anyEscalated returns true if any of builds are escalated.
+func anyEscalated(builds []build) bool {
+	for _, build := range builds {
+		if build.escalated {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestEscalateMarksBuildsFailingForLongerThanTheThreshold(t *testing.T) {
+	escalator := &Escalator{threshold: 30 * time.Minute}
+	now := time.Unix(1425590828, 0)
+	update := BuildUpdate{builds: []build{
+		{name: "Old Failure", buildState: BuildStateFailed,
+			failingSince: now.Add(-31 * time.Minute)},
+		{name: "New Failure", buildState: BuildStateFailed,
+			failingSince: now.Add(-29 * time.Minute)},
+		{name: "Acknowledged", buildState: BuildStateAcknowledged,
+			acknowledger: "Dave", failingSince: now.Add(-31 * time.Minute)},
+	}}
+
+	escalatedUpdate, changed, newlyEscalated := escalator.escalate(update, now)
+
+	assert.True(t, changed)
+	assert.True(t, escalatedUpdate.builds[0].escalated)
+	assert.False(t, escalatedUpdate.builds[1].escalated)
+	assert.False(t, escalatedUpdate.builds[2].escalated)
+	assert.False(t, update.builds[0].escalated, "The original update should not be changed")
+	if assert.Len(t, newlyEscalated, 1) {
+		assert.Equal(t, "Old Failure", newlyEscalated[0].name)
+	}
+
+	_, changed, newlyEscalated = escalator.escalate(update, now)
+	assert.False(t, changed)
+	assert.Empty(t, newlyEscalated, "Builds should only be escalated once")
+
+	_, changed, newlyEscalated = escalator.escalate(update, now.Add(time.Minute))
+	assert.True(t, changed)
+	assert.Len(t, newlyEscalated, 1)
+}
+
+func TestEscalateTimesBuildsWithoutAFailingSinceTimeFromWhenTheyFailed(t *testing.T) {
+	escalator := &Escalator{threshold: 30 * time.Minute}
+	now := time.Unix(1425590828, 0)
+	update := BuildUpdate{builds: []build{
+		{name: "Failure", buildState: BuildStateFailed},
+	}}
+
+	escalatedUpdate, _, _ := escalator.escalate(update, now)
+	assert.False(t, escalatedUpdate.builds[0].escalated)
+
+	escalatedUpdate, _, _ = escalator.escalate(update, now.Add(30*time.Minute))
+	assert.True(t, escalatedUpdate.builds[0].escalated)
+
+	// once acknowledged and failing again the timer starts again.
+	escalatedUpdate, changed, _ := escalator.escalate(BuildUpdate{builds: []build{
+		{name: "Failure", buildState: BuildStateAcknowledged, acknowledger: "Dave"},
+	}}, now.Add(31*time.Minute))
+	assert.True(t, changed)
+	assert.False(t, escalatedUpdate.builds[0].escalated)
+
+	escalatedUpdate, _, _ = escalator.escalate(update, now.Add(32*time.Minute))
+	assert.False(t, escalatedUpdate.builds[0].escalated)
+}
+
+func TestEscalationCommandIsGivenTheBuildDetails(t *testing.T) {
+	var command *exec.Cmd
+	escalator := &Escalator{
+		command: "./escalate.sh",
+		runCommand: func(c *exec.Cmd) error {
+			command = c
+			return nil
+		},
+	}
+	now := time.Unix(1425590828, 0)
+
+	escalator.runEscalationCommand(build{name: "payments-api", buildState: BuildStateFailed},
+		now.Add(-time.Hour), now)
+
+	assert.Equal(t, []string{"sh", "-c", "./escalate.sh"}, command.Args)
+	assert.Subset(t, command.Env, []string{
+		"MD_BUILD_NAME=payments-api",
+		"MD_BUILD_STATE=failed",
+		"MD_FAILING_SECONDS=3600",
+	})
+}
+
+func TestEscalatedFirstMovesEscalatedBuildsToTheFront(t *testing.T) {
+	builds := []build{
+		{name: "a"},
+		{name: "b", escalated: true},
+		{name: "c"},
+		{name: "d", escalated: true},
+	}
+
+	assert.Equal(t, []build{builds[1], builds[3], builds[0], builds[2]},
+		escalatedFirst(builds))
+}
+
+func TestEscalateKeepsTimingTheBuildsOfAServerThatFails(t *testing.T) {
+	escalator := &Escalator{threshold: 30 * time.Minute}
+	now := time.Unix(1425590828, 0)
+	bothServers := BuildUpdate{builds: []build{
+		{name: "payments-api", buildState: BuildStateFailed, source: "ci"},
+		{name: "search", buildState: BuildStateFailed, source: "release"},
+	}}
+	escalator.escalate(bothServers, now)
+
+	escalatedUpdate, changed, newlyEscalated := escalator.escalate(BuildUpdate{
+		builds: []build{
+			{name: "payments-api", buildState: BuildStateFailed, source: "ci"},
+		},
+		err: buildError{BuildErrorNetwork, "release: Network Error"},
+	}, now.Add(30*time.Minute))
+	assert.True(t, changed)
+	assert.Len(t, newlyEscalated, 1)
+	assert.True(t, escalatedUpdate.builds[0].escalated,
+		"The builds of working servers should be escalated")
+	assert.Error(t, escalatedUpdate.err)
+
+	escalatedUpdate, _, _ = escalator.escalate(bothServers, now.Add(31*time.Minute))
+	assert.True(t, escalatedUpdate.builds[1].escalated,
+		"Builds of a server that reconnects should still be timed from when they failed")
+}
+
+func TestEscalatorEscalatesBuildsBetweenUpdatesAsTimePasses(t *testing.T) {
+	fetcher := stubBuildFetcher{make(chan BuildUpdate)}
+	clock := NewFakeClock(time.Unix(1425590828, 0))
+	escalator := newEscalator(fetcher, 15*time.Second, "", clock)
+
+	fetcher.buildChannel <- BuildUpdate{builds: []build{
+		{name: "Failure", buildState: BuildStateFailed},
+	}}
+	assert.False(t, (<-escalator.BuildChannel()).builds[0].escalated)
+
+	clock.Advance(escalationCheckInterval)
+	clock.Advance(escalationCheckInterval)
+	assert.True(t, (<-escalator.BuildChannel()).builds[0].escalated,
+		"The build should be escalated once it has been failing for 15 seconds")
+}
@@ -0,0 +1,271 @@
+// Package fakemonitron provides a fake Monitron server speaking the
+// newline delimited JSON protocol, for developing and testing the
+// monitron dashboard without a real build server.
+package fakemonitron
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// Scenario configures the builds a Server reports and how it misbehaves.
+// Rates are probabilities between 0 and 1.
+type Scenario struct {
+	// Builds is the number of builds reported.
+	Builds int
+	// Interval is the time between updates.
+	Interval time.Duration
+	// FailureRate is the chance a build changes between passing and
+	// failing on each update.
+	FailureRate float64
+	// AcknowledgeRate is the chance an unacknowledged failing build is
+	// acknowledged on each update.
+	AcknowledgeRate float64
+	// BuildingRate is the chance a build starts or finishes building on
+	// each update.
+	BuildingRate float64
+	// MalformedRate is the chance an update is sent as a malformed line.
+	MalformedRate float64
+	// DisconnectRate is the chance a client is disconnected after each
+	// update.
+	DisconnectRate float64
+	// SplitWrites sends each line in several small writes, so clients
+	// have to deal with partial reads.
+	SplitWrites bool
+	// Seed seeds the random number generator, making a scenario
+	// repeatable.
+	Seed int64
+}
+
+// validate returns an error if the scenario can't be served.
+func (s Scenario) validate() error {
+	if s.Interval <= 0 {
+		return fmt.Errorf("interval must be more than 0, not %s", s.Interval)
+	}
+	return nil
+}
+
+// DefaultScenario is a gently changing set of builds that never
+// misbehaves.
+var DefaultScenario = Scenario{
+	Builds:          20,
+	Interval:        2 * time.Second,
+	FailureRate:     0.05,
+	AcknowledgeRate: 0.1,
+	BuildingRate:    0.1,
+	Seed:            1,
+}
+
+// acknowledgers are the users that acknowledge failing builds.
+var acknowledgers = []string{"Dave", "Sam", "Alex", "Jo"}
+
+// Build is the state of a single build.
+type Build struct {
+	Name             string `json:"name"`
+	Building         bool   `json:"building"`
+	Acknowledger     string `json:"user"`
+	URL              string `json:"url"`
+	NumberOfFailures int    `json:"number_of_failures"`
+	FailingSince     int64  `json:"failing_since"`
+}
+
+// failing returns true if the build is failing.
+func (b Build) failing() bool {
+	return b.NumberOfFailures > 0
+}
+
+// buildCollection is a single line of the Monitron protocol.
+type buildCollection struct {
+	Type         string  `json:"type"`
+	Error        string  `json:"error"`
+	Failing      []Build `json:"failing"`
+	Acknowledged []Build `json:"acknowledged"`
+	Healthy      []Build `json:"healthy"`
+}
+
+// Server is a fake Monitron server, its builds change at each interval
+// and every client is sent the current builds.
+type Server struct {
+	scenario Scenario
+
+	mutex  sync.Mutex
+	random *rand.Rand
+	builds []Build
+	now    time.Time
+}
+
+// NewServer creates a Server for scenario, all of its builds start off
+// passing.
+func NewServer(scenario Scenario) *Server {
+	server := &Server{
+		scenario: scenario,
+		random:   rand.New(rand.NewSource(scenario.Seed)),
+		builds:   make([]Build, scenario.Builds),
+		now:      time.Now(),
+	}
+	for i := range server.builds {
+		name := fmt.Sprintf("build-%03d", i+1)
+		server.builds[i] = Build{
+			Name: name,
+			URL:  fmt.Sprintf("http://localhost:8000/job/%s/", name),
+		}
+	}
+	return server
+}
+
+// ListenAndServe listens on address and serves clients.
+func (s *Server) ListenAndServe(address string) error {
+	if err := s.scenario.validate(); err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	return s.Serve(listener)
+}
+
+// Serve changes the builds at every interval and serves clients
+// accepted from listener, returning when accepting fails or straight
+// away if the scenario can't be served.
+func (s *Server) Serve(listener net.Listener) error {
+	if err := s.scenario.validate(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(s.scenario.Interval)
+	defer ticker.Stop()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case now := <-ticker.C:
+				s.Step(now)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveClient(conn)
+	}
+}
+
+// Step changes the builds as if time had moved on to now.
+func (s *Server) Step(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.now = now
+	for i := range s.builds {
+		build := &s.builds[i]
+		if s.chance(s.scenario.FailureRate) {
+			if build.failing() {
+				build.NumberOfFailures = 0
+				build.FailingSince = 0
+				build.Acknowledger = ""
+			} else {
+				build.NumberOfFailures = 1
+				build.FailingSince = now.UnixNano() / int64(time.Millisecond)
+			}
+		}
+		if build.failing() && build.Acknowledger == "" &&
+			s.chance(s.scenario.AcknowledgeRate) {
+			build.Acknowledger = acknowledgers[s.random.Intn(len(acknowledgers))]
+		}
+		if s.chance(s.scenario.BuildingRate) {
+			if build.Building && build.failing() {
+				// the build finished without fixing anything.
+				build.NumberOfFailures++
+			}
+			build.Building = !build.Building
+		}
+	}
+}
+
+// Line returns the next line to send to a client, which may be
+// malformed, and whether the client should be disconnected after it.
+func (s *Server) Line() (line []byte, disconnect bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.chance(s.scenario.MalformedRate) {
+		line = []byte("{\"type\":\"builds\",\"failing\":[{\"name\":\n")
+	} else {
+		line = s.snapshot()
+	}
+	return line, s.chance(s.scenario.DisconnectRate)
+}
+
+// snapshot encodes the current builds as a line of the protocol.
+func (s *Server) snapshot() []byte {
+	collection := buildCollection{
+		Type:         "builds",
+		Failing:      []Build{},
+		Acknowledged: []Build{},
+		Healthy:      []Build{},
+	}
+	for _, build := range s.builds {
+		switch {
+		case build.failing() && build.Acknowledger != "":
+			collection.Acknowledged = append(collection.Acknowledged, build)
+		case build.failing():
+			collection.Failing = append(collection.Failing, build)
+		default:
+			collection.Healthy = append(collection.Healthy, build)
+		}
+	}
+
+	line, _ := json.Marshal(collection)
+	return append(line, '\n')
+}
+
+// chance returns true with probability rate, it must be called with the
+// mutex held.
+func (s *Server) chance(rate float64) bool {
+	return rate > 0 && s.random.Float64() < rate
+}
+
+// serveClient sends lines to conn every interval until it is
+// disconnected.
+func (s *Server) serveClient(conn net.Conn) {
+	defer conn.Close()
+	for {
+		line, disconnect := s.Line()
+		if err := s.write(conn, line); err != nil || disconnect {
+			return
+		}
+		time.Sleep(s.scenario.Interval)
+	}
+}
+
+// write writes line to conn, in pieces if the scenario splits writes.
+func (s *Server) write(conn net.Conn, line []byte) error {
+	if !s.scenario.SplitWrites {
+		_, err := conn.Write(line)
+		return err
+	}
+
+	pieceLength := len(line)/3 + 1
+	for len(line) > 0 {
+		if pieceLength > len(line) {
+			pieceLength = len(line)
+		}
+		if _, err := conn.Write(line[:pieceLength]); err != nil {
+			return err
+		}
+		line = line[pieceLength:]
+		time.Sleep(time.Millisecond)
+	}
+	return nil
+}
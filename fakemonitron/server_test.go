@@ -0,0 +1,91 @@
+package fakemonitron
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNewServerStartsWithPassingBuilds(t *testing.T) {
+	server := NewServer(Scenario{Builds: 3})
+
+	line, disconnect := server.Line()
+
+	var collection buildCollection
+	assert.NoError(t, json.Unmarshal(line, &collection))
+	assert.False(t, disconnect)
+	assert.Equal(t, "builds", collection.Type)
+	assert.Empty(t, collection.Failing)
+	assert.Len(t, collection.Healthy, 3)
+	assert.Equal(t, "build-001", collection.Healthy[0].Name)
+}
+
+func TestStepFailsAndAcknowledgesBuilds(t *testing.T) {
+	server := NewServer(Scenario{Builds: 2, FailureRate: 1})
+	now := time.Unix(1425590828, 0)
+
+	server.Step(now)
+	line, _ := server.Line()
+
+	var collection buildCollection
+	assert.NoError(t, json.Unmarshal(line, &collection))
+	assert.Len(t, collection.Failing, 2)
+	assert.Equal(t, int64(1425590828000), collection.Failing[0].FailingSince)
+
+	server.scenario = Scenario{AcknowledgeRate: 1}
+	server.Step(now)
+	line, _ = server.Line()
+
+	assert.NoError(t, json.Unmarshal(line, &collection))
+	assert.Empty(t, collection.Failing)
+	assert.Len(t, collection.Acknowledged, 2)
+	assert.NotEmpty(t, collection.Acknowledged[0].Acknowledger)
+}
+
+func TestLineCanBeMalformedOrDisconnect(t *testing.T) {
+	server := NewServer(Scenario{Builds: 1, MalformedRate: 1, DisconnectRate: 1})
+
+	line, disconnect := server.Line()
+
+	var collection buildCollection
+	assert.Error(t, json.Unmarshal(line, &collection))
+	assert.True(t, disconnect)
+}
+
+func TestServeSendsSplitLinesToClients(t *testing.T) {
+	server := NewServer(Scenario{Builds: 5, Interval: time.Hour, SplitWrites: true})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error listening: %s", err)
+	}
+	defer listener.Close()
+	go server.Serve(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error connecting: %s", err)
+	}
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	assert.NoError(t, err)
+	var collection buildCollection
+	assert.NoError(t, json.Unmarshal([]byte(line), &collection))
+	assert.Len(t, collection.Healthy, 5)
+}
+
+func TestServeNeedsAnInterval(t *testing.T) {
+	server := NewServer(Scenario{Builds: 5})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error listening: %s", err)
+	}
+	defer listener.Close()
+
+	assert.EqualError(t, server.Serve(listener), "interval must be more than 0, not 0s")
+	assert.EqualError(t, server.ListenAndServe("127.0.0.1:0"),
+		"interval must be more than 0, not 0s")
+}
@@ -0,0 +1,85 @@
+package monitrondashboard
+
+// The frame buffer for the monitron dashboard.
+// Here you'll find a Screen that draws each frame off screen and only
+// sends the cells that have changed since the last frame to the terminal,
+// so redrawing doesn't flicker or use much bandwidth over a slow link.
+
+// FrameBuffer is a Screen that wraps another Screen, drawing into a
+// buffer that is compared to the last frame when it is flushed.
+type FrameBuffer struct {
+	Screen
+	width   int
+	height  int
+	next    []Cell
+	current []Cell
+	// stale is set when the screen may not show the current frame, such
+	// as after it is resized, so every cell is sent on the next flush.
+	stale bool
+}
+
+// NewFrameBuffer creates a FrameBuffer drawing on screen.
+func NewFrameBuffer(screen Screen) *FrameBuffer {
+	return &FrameBuffer{Screen: screen, stale: true}
+}
+
+func (f *FrameBuffer) Init(colours ColourMode) error {
+	f.stale = true
+	return f.Screen.Init(colours)
+}
+
+// SetColourMode changes the colours the screen draws in, sending every
+// cell on the next flush to draw them in the new colours.
+func (f *FrameBuffer) SetColourMode(colours ColourMode) {
+	f.stale = true
+	f.Screen.SetColourMode(colours)
+}
+
+// Clear blanks every cell of the next frame with fg and bg.
+func (f *FrameBuffer) Clear(fg, bg Colour) {
+	f.fitScreen()
+	for i := range f.next {
+		f.next[i] = Cell{Ch: ' ', Fg: fg, Bg: bg}
+	}
+}
+
+// SetCell draws ch at x, y in the next frame.
+func (f *FrameBuffer) SetCell(x, y int, ch rune, fg, bg Colour) {
+	if x < 0 || y < 0 || x >= f.width || y >= f.height {
+		return
+	}
+	f.next[y*f.width+x] = Cell{Ch: ch, Fg: fg, Bg: bg}
+}
+
+// Flush sends the cells that differ from the last frame to the screen.
+func (f *FrameBuffer) Flush() {
+	f.fitScreen()
+	for i, cell := range f.next {
+		if f.stale || cell != f.current[i] {
+			f.Screen.SetCell(i%f.width, i/f.width, cell.Ch, cell.Fg, cell.Bg)
+			f.current[i] = cell
+		}
+	}
+	f.stale = false
+	f.Screen.Flush()
+}
+
+// fitScreen resizes the buffers to the size of the screen if it has
+// changed, keeping the cells of the next frame that still fit.
+func (f *FrameBuffer) fitScreen() {
+	width, height := f.Screen.Size()
+	if width == f.width && height == f.height {
+		return
+	}
+	next := make([]Cell, width*height)
+	for i := range next {
+		next[i] = blankCell
+		x, y := i%width, i/width
+		if x < f.width && y < f.height {
+			next[i] = f.next[y*f.width+x]
+		}
+	}
+	f.width, f.height = width, height
+	f.next, f.current = next, make([]Cell, width*height)
+	f.stale = true
+}
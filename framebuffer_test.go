@@ -0,0 +1,203 @@
+package monitrondashboard
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"sync"
+	"testing"
+)
+
+// cellCounter is a VirtualScreen counting the cells drawn on it.
+type cellCounter struct {
+	*VirtualScreen
+	cells int
+}
+
+func (c *cellCounter) SetCell(x, y int, ch rune, fg, bg Colour) {
+	c.cells++
+	c.VirtualScreen.SetCell(x, y, ch, fg, bg)
+}
+
+func TestFrameBufferOnlySendsChangedCells(t *testing.T) {
+	screen := &cellCounter{VirtualScreen: NewVirtualScreen(10, 3)}
+	frameBuffer := NewFrameBuffer(screen)
+
+	frameBuffer.Clear(ColourWhite, ColourBlack)
+	frameBuffer.SetCell(1, 1, 'a', ColourRed, ColourBlack)
+	assert.Equal(t, 0, screen.cells, "Nothing should be drawn until the frame is flushed")
+	frameBuffer.Flush()
+	assert.Equal(t, 30, screen.cells, "The first frame should be drawn in full")
+	assert.Equal(t, Cell{'a', ColourRed, ColourBlack}, screen.Cell(1, 1))
+
+	screen.cells = 0
+	frameBuffer.Clear(ColourWhite, ColourBlack)
+	frameBuffer.SetCell(1, 1, 'a', ColourRed, ColourBlack)
+	frameBuffer.SetCell(2, 1, 'b', ColourRed, ColourBlack)
+	frameBuffer.Flush()
+	assert.Equal(t, 1, screen.cells, "Only the new cell should be drawn")
+	assert.Equal(t, Cell{'b', ColourRed, ColourBlack}, screen.Cell(2, 1))
+
+	screen.cells = 0
+	frameBuffer.Clear(ColourWhite, ColourBlack)
+	frameBuffer.SetCell(2, 1, 'b', ColourRed|AttrBold, ColourBlack)
+	frameBuffer.Flush()
+	assert.Equal(t, 2, screen.cells, "The cleared and restyled cells should be drawn")
+	assert.Equal(t, Cell{' ', ColourWhite, ColourBlack}, screen.Cell(1, 1))
+}
+
+func TestFrameBufferRedrawsEverythingWhenTheScreenChanges(t *testing.T) {
+	screen := &cellCounter{VirtualScreen: NewVirtualScreen(10, 3)}
+	frameBuffer := NewFrameBuffer(screen)
+	frameBuffer.Clear(ColourWhite, ColourBlack)
+	frameBuffer.Flush()
+
+	screen.cells = 0
+	screen.Resize(12, 4)
+	frameBuffer.Clear(ColourWhite, ColourBlack)
+	frameBuffer.Flush()
+	assert.Equal(t, 48, screen.cells, "Every cell should be drawn after a resize")
+
+	screen.cells = 0
+	frameBuffer.SetColourMode(ColourMode16)
+	frameBuffer.Flush()
+	assert.Equal(t, 48, screen.cells, "Every cell should be drawn in new colours")
+}
+
+func TestFrameBufferIgnoresCellsOffTheScreen(t *testing.T) {
+	screen := &cellCounter{VirtualScreen: NewVirtualScreen(10, 3)}
+	frameBuffer := NewFrameBuffer(screen)
+	frameBuffer.Clear(ColourWhite, ColourBlack)
+
+	frameBuffer.SetCell(10, 0, 'a', ColourWhite, ColourBlack)
+	frameBuffer.SetCell(0, -1, 'a', ColourWhite, ColourBlack)
+	frameBuffer.Flush()
+	assert.Equal(t, "\n\n\n", screen.Text())
+}
+
+// countingTty is a tcell.Tty of a fixed size that counts the bytes
+// written to it, standing in for a terminal at the end of a slow link.
+type countingTty struct {
+	width   int
+	height  int
+	drained chan bool
+	drain   sync.Once
+
+	mutex   sync.Mutex
+	written int
+}
+
+func newCountingTty(width, height int) *countingTty {
+	return &countingTty{width: width, height: height, drained: make(chan bool)}
+}
+
+func (c *countingTty) Start() error { return nil }
+func (c *countingTty) Stop() error  { return nil }
+func (c *countingTty) Close() error { return nil }
+
+func (c *countingTty) Drain() error {
+	c.drain.Do(func() { close(c.drained) })
+	return nil
+}
+
+func (c *countingTty) NotifyResize(func()) {}
+
+func (c *countingTty) WindowSize() (tcell.WindowSize, error) {
+	return tcell.WindowSize{Width: c.width, Height: c.height}, nil
+}
+
+// Read has no input to give until the tty is drained.
+func (c *countingTty) Read([]byte) (int, error) {
+	<-c.drained
+	return 0, io.EOF
+}
+
+func (c *countingTty) Write(p []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.written += len(p)
+	return len(p), nil
+}
+
+// takeWritten returns the number of bytes written since it was last
+// called.
+func (c *countingTty) takeWritten() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	written := c.written
+	c.written = 0
+	return written
+}
+
+// benchmarkBuilds returns count builds, a mix of every state.
+func benchmarkBuilds(count int) []build {
+	builds := make([]build, count)
+	for i := range builds {
+		builds[i] = build{
+			name:       fmt.Sprintf("build-%03d", i),
+			buildState: buildStates[i%len(buildStates)],
+			building:   i%7 == 0,
+		}
+	}
+	return builds
+}
+
+// benchmarkRedraw redraws 300 builds b.N times on an xterm drawn with
+// tcell, changing the state of one build before each redraw if changing
+// is set, and reports how many bytes were written to the terminal per
+// redraw.
+func benchmarkRedraw(b *testing.B, wrap func(Screen) Screen, changing bool) {
+	terminfo, err := tcell.LookupTerminfo("xterm-256color")
+	if err != nil {
+		b.Fatal(err)
+	}
+	tty := newCountingTty(320, 200)
+	screen := wrap(&TcellScreen{newScreen: func() (tcell.Screen, error) {
+		return tcell.NewTerminfoScreenFromTtyTerminfo(tty, terminfo)
+	}})
+	if err := screen.Init(ColourMode256); err != nil {
+		b.Fatal(err)
+	}
+	defer screen.Close()
+	dashboard := NewDashboard(nil, screen)
+	builds := benchmarkBuilds(300)
+	if err := dashboard.updateBuilds(BuildUpdate{builds: builds}); err != nil {
+		b.Fatal(err)
+	}
+
+	tty.takeWritten()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if changing {
+			changed := &builds[i%len(builds)]
+			changed.buildState = buildStates[(int(changed.buildState)+1)%len(buildStates)]
+		}
+		dashboard.updateBuilds(BuildUpdate{builds: builds})
+	}
+	b.ReportMetric(float64(tty.takeWritten())/float64(b.N), "bytes/op")
+}
+
+func BenchmarkRedraw300Builds(b *testing.B) {
+	benchmarkRedraw(b, unbuffered, false)
+}
+
+func BenchmarkRedraw300BuildsWithAFrameBuffer(b *testing.B) {
+	benchmarkRedraw(b, buffered, false)
+}
+
+func BenchmarkRedraw300BuildsChangingOne(b *testing.B) {
+	benchmarkRedraw(b, unbuffered, true)
+}
+
+func BenchmarkRedraw300BuildsChangingOneWithAFrameBuffer(b *testing.B) {
+	benchmarkRedraw(b, buffered, true)
+}
+
+func unbuffered(screen Screen) Screen {
+	return screen
+}
+
+func buffered(screen Screen) Screen {
+	return NewFrameBuffer(screen)
+}
@@ -0,0 +1,103 @@
+package monitrondashboard
+
+// JSON output for the monitron dashboard.
+// Here you'll find code for streaming build updates as newline delimited
+// JSON so they can be consumed by jq and other scripts.
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonBuildUpdate is the structure written for each BuildUpdate.
+type jsonBuildUpdate struct {
+	Builds []jsonOutputBuild `json:"builds"`
+	Error  *jsonOutputError  `json:"error"`
+}
+
+// jsonOutputBuild is a normalised build as written by the JSONPrinter.
+type jsonOutputBuild struct {
+	Name         string `json:"name"`
+	State        string `json:"state"`
+	Building     bool   `json:"building"`
+	Acknowledger string `json:"acknowledger"`
+	Escalated    bool   `json:"escalated"`
+	Source       string `json:"source,omitempty"`
+}
+
+// jsonOutputError describes an error received with a BuildUpdate.
+type jsonOutputError struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// JSONPrinter writes every BuildUpdate received from a BuildFetcher to
+// a writer as a single line of JSON.
+type JSONPrinter struct {
+	fetcher BuildFetcher
+	writer  io.Writer
+}
+
+// NewJSONPrinter creates a JSONPrinter that writes updates from fetcher
+// to writer.
+func NewJSONPrinter(fetcher BuildFetcher, writer io.Writer) JSONPrinter {
+	return JSONPrinter{
+		fetcher: fetcher,
+		writer:  writer,
+	}
+}
+
+// Run writes build updates as they arrive, returning only if writing
+// fails, e.g. when the reading end of a pipe has gone away.
+func (p JSONPrinter) Run() error {
+	for buildUpdate := range p.fetcher.BuildChannel() {
+		if err := p.writeBuildUpdate(buildUpdate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBuildUpdate writes a single BuildUpdate as a line of JSON.
+func (p JSONPrinter) writeBuildUpdate(buildUpdate BuildUpdate) error {
+	// json.Encoder terminates every value with a newline.
+	return json.NewEncoder(p.writer).Encode(newJSONBuildUpdate(buildUpdate))
+}
+
+// newJSONBuildUpdate converts a BuildUpdate into its normalised JSON form.
+func newJSONBuildUpdate(buildUpdate BuildUpdate) jsonBuildUpdate {
+	output := jsonBuildUpdate{
+		Builds: make([]jsonOutputBuild, 0, len(buildUpdate.builds)),
+	}
+	for _, build := range buildUpdate.builds {
+		output.Builds = append(output.Builds, newJSONOutputBuild(build))
+	}
+	if buildUpdate.err != nil {
+		output.Error = &jsonOutputError{
+			Kind:    errorKindName(buildUpdate.err),
+			Message: buildUpdate.err.Error(),
+		}
+	}
+	return output
+}
+
+// newJSONOutputBuild converts a build into its normalised JSON form.
+func newJSONOutputBuild(build build) jsonOutputBuild {
+	return jsonOutputBuild{
+		Name:         build.name,
+		State:        build.buildState.String(),
+		Building:     build.building,
+		Acknowledger: build.acknowledger,
+		Escalated:    build.escalated,
+		Source:       build.source,
+	}
+}
+
+// errorKindName returns the name of the kind of err, or "unknown" for
+// errors that didn't come from a BuildFetcher.
+func errorKindName(err error) string {
+	if buildErr, ok := err.(buildError); ok {
+		return buildErr.kind.String()
+	}
+	return "unknown"
+}
@@ -0,0 +1,51 @@
+package monitrondashboard
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestJSONPrinterWritesABuildUpdateAsALine(t *testing.T) {
+	var buffer bytes.Buffer
+	printer := NewJSONPrinter(nil, &buffer)
+
+	err := printer.writeBuildUpdate(BuildUpdate{
+		builds: []build{
+			{name: "Build", buildState: BuildStatePassed},
+			{name: "Failing Build", buildState: BuildStateAcknowledged, building: true, acknowledger: "Dave"},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"builds":[`+
+		`{"name":"Build","state":"passed","building":false,"acknowledger":"","escalated":false},`+
+		`{"name":"Failing Build","state":"acknowledged","building":true,"acknowledger":"Dave","escalated":false}`+
+		`],"error":null}`+"\n", buffer.String())
+}
+
+var jsonPrinterErrorTests = []struct {
+	err error
+	out string
+}{
+	{buildError{BuildErrorNetwork, "Network Error"},
+		`{"builds":[],"error":{"kind":"network","message":"Network Error"}}` + "\n"},
+	{buildError{BuildErrorParse, "Cannot Parse JSON"},
+		`{"builds":[],"error":{"kind":"parse","message":"Cannot Parse JSON"}}` + "\n"},
+	{errors.New("Other"),
+		`{"builds":[],"error":{"kind":"unknown","message":"Other"}}` + "\n"},
+}
+
+func TestJSONPrinterClassifiesErrors(t *testing.T) {
+	for _, test := range jsonPrinterErrorTests {
+		var buffer bytes.Buffer
+		printer := NewJSONPrinter(nil, &buffer)
+
+		assert.NoError(t, printer.writeBuildUpdate(BuildUpdate{
+			builds: []build{},
+			err:    test.err,
+		}))
+		assert.Equal(t, test.out, buffer.String())
+	}
+}
@@ -0,0 +1,100 @@
+package monitrondashboard
+
+// Merging builds from several servers for the monitron dashboard.
+// Here you'll find code for showing the builds from more than one
+// Monitron server on the same dashboard.
+
+import (
+	"fmt"
+)
+
+// BuildSource is a named BuildFetcher whose builds are merged with
+// others by a MergedBuildFetcher.
+type BuildSource struct {
+	Name    string
+	Fetcher BuildFetcher
+}
+
+// sourceUpdate is a BuildUpdate from the source at index.
+type sourceUpdate struct {
+	index int
+	BuildUpdate
+}
+
+// MergedBuildFetcher is a BuildFetcher that merges the latest builds
+// from each of its sources into one update, tagging each build with the
+// name of its source.
+type MergedBuildFetcher struct {
+	sources      []BuildSource
+	buildChannel chan BuildUpdate
+}
+
+// NewMergedBuildFetcher creates a MergedBuildFetcher merging the builds
+// from sources, in the order they are given.
+func NewMergedBuildFetcher(sources []BuildSource) *MergedBuildFetcher {
+	merged := &MergedBuildFetcher{
+		sources:      sources,
+		buildChannel: make(chan BuildUpdate),
+	}
+	go merged.forwardBuilds()
+	return merged
+}
+
+func (m *MergedBuildFetcher) BuildChannel() chan BuildUpdate {
+	return m.buildChannel
+}
+
+// forwardBuilds sends a merged update whenever any source sends one,
+// closing the channel once every source has finished.
+func (m *MergedBuildFetcher) forwardBuilds() {
+	updates := make(chan sourceUpdate)
+	done := make(chan bool)
+	for i, source := range m.sources {
+		go func(index int, fetcher BuildFetcher) {
+			for buildUpdate := range fetcher.BuildChannel() {
+				updates <- sourceUpdate{index, buildUpdate}
+			}
+			done <- true
+		}(i, source.Fetcher)
+	}
+
+	latest := make([]*BuildUpdate, len(m.sources))
+	for running := len(m.sources); running > 0; {
+		select {
+		case update := <-updates:
+			latest[update.index] = &update.BuildUpdate
+			m.buildChannel <- m.merge(latest)
+		case <-done:
+			running--
+		}
+	}
+	close(m.buildChannel)
+}
+
+// merge returns the builds from every source that has sent an update
+// without an error, along with the first error from a source prefixed
+// with its name.
+func (m *MergedBuildFetcher) merge(latest []*BuildUpdate) BuildUpdate {
+	merged := BuildUpdate{builds: []build{}}
+	for i, buildUpdate := range latest {
+		if buildUpdate == nil {
+			continue
+		}
+		if buildUpdate.err != nil {
+			if merged.err == nil {
+				kind := BuildErrorNetwork
+				if err, ok := buildUpdate.err.(buildError); ok {
+					kind = err.kind
+				}
+				merged.err = buildError{kind,
+					fmt.Sprintf("%s: %s", m.sources[i].Name, buildUpdate.err)}
+			}
+			continue
+		}
+		for _, build := range buildUpdate.builds {
+			build.source = m.sources[i].Name
+			merged.builds = append(merged.builds, build)
+		}
+	}
+	return merged
+}
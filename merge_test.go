@@ -0,0 +1,66 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMergedBuildFetcherMergesTheLatestBuildsFromEachSource(t *testing.T) {
+	first := stubBuildFetcher{make(chan BuildUpdate)}
+	second := stubBuildFetcher{make(chan BuildUpdate)}
+	merged := NewMergedBuildFetcher([]BuildSource{
+		{Name: "first", Fetcher: first},
+		{Name: "second", Fetcher: second},
+	})
+
+	first.buildChannel <- BuildUpdate{builds: []build{{name: "A"}}}
+	update := <-merged.BuildChannel()
+	assert.Equal(t, []build{{name: "A", source: "first"}}, update.builds)
+
+	second.buildChannel <- BuildUpdate{builds: []build{{name: "B"}}}
+	update = <-merged.BuildChannel()
+	assert.Equal(t, []build{
+		{name: "A", source: "first"},
+		{name: "B", source: "second"},
+	}, update.builds)
+
+	first.buildChannel <- BuildUpdate{builds: []build{{name: "C"}}}
+	update = <-merged.BuildChannel()
+	assert.Equal(t, []build{
+		{name: "C", source: "first"},
+		{name: "B", source: "second"},
+	}, update.builds)
+}
+
+func TestMergedBuildFetcherSendsErrorsPrefixedWithTheSourceAlongWithOtherBuilds(t *testing.T) {
+	first := stubBuildFetcher{make(chan BuildUpdate)}
+	second := stubBuildFetcher{make(chan BuildUpdate)}
+	merged := NewMergedBuildFetcher([]BuildSource{
+		{Name: "first", Fetcher: first},
+		{Name: "second", Fetcher: second},
+	})
+
+	first.buildChannel <- BuildUpdate{builds: []build{{name: "A"}}}
+	<-merged.BuildChannel()
+	second.buildChannel <- BuildUpdate{builds: []build{},
+		err: buildError{BuildErrorNetwork, "Error Connecting"}}
+	update := <-merged.BuildChannel()
+
+	assert.Equal(t, buildError{BuildErrorNetwork, "second: Error Connecting"}, update.err)
+	assert.Equal(t, []build{{name: "A", source: "first"}}, update.builds)
+}
+
+func TestMergedBuildFetcherClosesWhenEverySourceHasFinished(t *testing.T) {
+	first := stubBuildFetcher{make(chan BuildUpdate)}
+	second := stubBuildFetcher{make(chan BuildUpdate)}
+	merged := NewMergedBuildFetcher([]BuildSource{
+		{Name: "first", Fetcher: first},
+		{Name: "second", Fetcher: second},
+	})
+
+	close(first.buildChannel)
+	close(second.buildChannel)
+
+	_, ok := <-merged.BuildChannel()
+	assert.False(t, ok)
+}
@@ -0,0 +1,192 @@
+package monitrondashboard
+
+// Prometheus metrics for the monitron dashboard.
+// Here you'll find code for recording build updates as they pass from a
+// BuildFetcher to the dashboard and exposing them over HTTP in the
+// Prometheus text format.
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// buildStates is every buildState, in the order they are exported.
+var buildStates = []buildState{
+	BuildStateFailed,
+	BuildStateAcknowledged,
+	BuildStatePassed,
+	BuildStateUnknown,
+}
+
+// MetricsExporter is a BuildFetcher that wraps another BuildFetcher,
+// recording metrics for every BuildUpdate before passing it on. It is
+// also an http.Handler serving the recorded metrics.
+type MetricsExporter struct {
+	fetcher      BuildFetcher
+	buildChannel chan BuildUpdate
+	clock        Clock
+
+	mutex         sync.Mutex
+	builds        []build
+	up            bool
+	lastUpdate    time.Time
+	networkErrors int
+	parseErrors   int
+}
+
+// NewMetricsExporter creates a MetricsExporter recording the updates
+// from fetcher.
+func NewMetricsExporter(fetcher BuildFetcher) *MetricsExporter {
+	return newMetricsExporter(fetcher, SystemClock)
+}
+
+// newMetricsExporter creates a MetricsExporter timing the updates from
+// fetcher with clock.
+func newMetricsExporter(fetcher BuildFetcher, clock Clock) *MetricsExporter {
+	exporter := &MetricsExporter{
+		fetcher:      fetcher,
+		buildChannel: make(chan BuildUpdate),
+		clock:        clock,
+		builds:       []build{},
+	}
+	go exporter.forwardBuilds()
+	return exporter
+}
+
+func (m *MetricsExporter) BuildChannel() chan BuildUpdate {
+	return m.buildChannel
+}
+
+// forwardBuilds records and passes on every update from the wrapped
+// fetcher.
+func (m *MetricsExporter) forwardBuilds() {
+	for buildUpdate := range m.fetcher.BuildChannel() {
+		m.recordBuildUpdate(buildUpdate, m.clock.Now())
+		m.buildChannel <- buildUpdate
+	}
+	close(m.buildChannel)
+}
+
+// recordBuildUpdate updates the metrics for a BuildUpdate received at now.
+// An update with an error still records any builds it has, which are the
+// builds of the servers that are working.
+func (m *MetricsExporter) recordBuildUpdate(buildUpdate BuildUpdate, now time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if buildUpdate.err != nil {
+		if buildErr, ok := buildUpdate.err.(buildError); ok && buildErr.kind == BuildErrorParse {
+			m.parseErrors++
+		} else {
+			m.networkErrors++
+			m.up = false
+		}
+		if len(buildUpdate.builds) == 0 {
+			return
+		}
+	} else {
+		m.up = true
+	}
+	m.builds = buildUpdate.builds
+	m.lastUpdate = now
+}
+
+func (m *MetricsExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(m.metrics(m.clock.Now()))
+}
+
+// metrics returns the metrics in the Prometheus text format, using now
+// to work out how long builds have been failing.
+func (m *MetricsExporter) metrics(now time.Time) []byte {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var buffer bytes.Buffer
+	metric := func(name, metricType, help string) {
+		fmt.Fprintf(&buffer, "# HELP %s %s\n# TYPE %s %s\n",
+			name, help, name, metricType)
+	}
+
+	metric("monidash_up", "gauge",
+		"Whether the last update from the Monitron server was received successfully.")
+	fmt.Fprintf(&buffer, "monidash_up %d\n", boolToInt(m.up))
+
+	metric("monidash_last_update_timestamp_seconds", "gauge",
+		"Unix time of the last successful update from the Monitron server.")
+	lastUpdate := int64(0)
+	if !m.lastUpdate.IsZero() {
+		lastUpdate = m.lastUpdate.Unix()
+	}
+	fmt.Fprintf(&buffer, "monidash_last_update_timestamp_seconds %d\n", lastUpdate)
+
+	metric("monidash_network_errors_total", "counter",
+		"Number of network errors talking to the Monitron server.")
+	fmt.Fprintf(&buffer, "monidash_network_errors_total %d\n", m.networkErrors)
+
+	metric("monidash_parse_errors_total", "counter",
+		"Number of updates from the Monitron server that could not be parsed.")
+	fmt.Fprintf(&buffer, "monidash_parse_errors_total %d\n", m.parseErrors)
+
+	metric("monidash_builds", "gauge", "Number of builds in each state.")
+	stateCounts := map[buildState]int{}
+	for _, build := range m.builds {
+		stateCounts[build.buildState]++
+	}
+	for _, state := range buildStates {
+		fmt.Fprintf(&buffer, "monidash_builds{state=\"%s\"} %d\n",
+			state, stateCounts[state])
+	}
+
+	metric("monidash_build_state", "gauge",
+		"1 for the state each build is currently in, 0 for the others.")
+	for _, build := range m.builds {
+		for _, state := range buildStates {
+			fmt.Fprintf(&buffer,
+				"monidash_build_state{build=\"%s\",state=\"%s\"} %d\n",
+				escapeLabelValue(build.name), state,
+				boolToInt(build.buildState == state))
+		}
+	}
+
+	metric("monidash_build_building", "gauge",
+		"1 if the build is currently building.")
+	for _, build := range m.builds {
+		fmt.Fprintf(&buffer, "monidash_build_building{build=\"%s\"} %d\n",
+			escapeLabelValue(build.name), boolToInt(build.building))
+	}
+
+	metric("monidash_build_failing_seconds", "gauge",
+		"Number of seconds a failing build has been failing for.")
+	for _, build := range m.builds {
+		if build.failingSince.IsZero() {
+			continue
+		}
+		fmt.Fprintf(&buffer, "monidash_build_failing_seconds{build=\"%s\"} %d\n",
+			escapeLabelValue(build.name),
+			int64(now.Sub(build.failingSince)/time.Second))
+	}
+
+	return buffer.Bytes()
+}
+
+// labelValueEscaper escapes the characters Prometheus doesn't allow in
+// label values.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes value so it can be used as a label value.
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+// boolToInt returns 1 for true and 0 for false.
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
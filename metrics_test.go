@@ -0,0 +1,106 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsExporterRecordsBuilds(t *testing.T) {
+	exporter := &MetricsExporter{}
+	updateTime := time.Unix(1425590900, 0)
+	exporter.recordBuildUpdate(BuildUpdate{
+		builds: []build{
+			{name: "Build", buildState: BuildStatePassed, building: true},
+			{name: "Failing \"Build\"", buildState: BuildStateFailed,
+				failingSince: time.Unix(1425590828, 0)},
+		},
+	}, updateTime)
+
+	metrics := string(exporter.metrics(updateTime.Add(28 * time.Second)))
+
+	for _, expected := range []string{
+		"monidash_up 1\n",
+		"monidash_last_update_timestamp_seconds 1425590900\n",
+		"monidash_builds{state=\"failed\"} 1\n",
+		"monidash_builds{state=\"acknowledged\"} 0\n",
+		"monidash_builds{state=\"passed\"} 1\n",
+		"monidash_build_state{build=\"Build\",state=\"passed\"} 1\n",
+		"monidash_build_state{build=\"Build\",state=\"failed\"} 0\n",
+		"monidash_build_state{build=\"Failing \\\"Build\\\"\",state=\"failed\"} 1\n",
+		"monidash_build_building{build=\"Build\"} 1\n",
+		"monidash_build_failing_seconds{build=\"Failing \\\"Build\\\"\"} 100\n",
+	} {
+		assert.Contains(t, metrics, expected)
+	}
+	assert.False(t, strings.Contains(metrics, "monidash_build_failing_seconds{build=\"Build\"}"),
+		"Passing builds should not have a failing time")
+}
+
+func TestMetricsExporterCountsErrors(t *testing.T) {
+	exporter := &MetricsExporter{}
+	now := time.Now()
+	exporter.recordBuildUpdate(BuildUpdate{builds: []build{}}, now)
+	exporter.recordBuildUpdate(BuildUpdate{
+		err: buildError{BuildErrorParse, "Cannot Parse JSON"},
+	}, now)
+
+	metrics := string(exporter.metrics(now))
+	assert.Contains(t, metrics, "monidash_up 1\n",
+		"A parse error should not mark the connection as down")
+	assert.Contains(t, metrics, "monidash_parse_errors_total 1\n")
+
+	exporter.recordBuildUpdate(BuildUpdate{
+		err: buildError{BuildErrorNetwork, "Network Error"},
+	}, now)
+
+	metrics = string(exporter.metrics(now))
+	assert.Contains(t, metrics, "monidash_up 0\n")
+	assert.Contains(t, metrics, "monidash_network_errors_total 1\n")
+}
+
+func TestMetricsExporterPassesOnBuildUpdates(t *testing.T) {
+	fetcher := stubBuildFetcher{make(chan BuildUpdate, 1)}
+	exporter := newMetricsExporter(fetcher, NewFakeClock(time.Unix(1425590900, 0)))
+	update := BuildUpdate{builds: []build{{name: "Build"}}}
+
+	fetcher.buildChannel <- update
+
+	assert.Equal(t, update, <-exporter.BuildChannel())
+	assert.Contains(t, string(exporter.metrics(time.Unix(1425590900, 0))),
+		"monidash_last_update_timestamp_seconds 1425590900\n",
+		"The update should be timed by the exporter's clock")
+}
+
+// stubBuildFetcher is a BuildFetcher handing out a channel controlled
+// by the test.
+type stubBuildFetcher struct {
+	buildChannel chan BuildUpdate
+}
+
+func (s stubBuildFetcher) BuildChannel() chan BuildUpdate {
+	return s.buildChannel
+}
+
+func TestMetricsExporterRecordsTheBuildsOfWorkingServersWhenAnotherFails(t *testing.T) {
+	exporter := &MetricsExporter{}
+	updateTime := time.Unix(1425590900, 0)
+	exporter.recordBuildUpdate(BuildUpdate{
+		builds: []build{
+			{name: "payments-api", buildState: BuildStateFailed, source: "ci"},
+		},
+		err: buildError{BuildErrorNetwork, "release: Network Error"},
+	}, updateTime)
+
+	metrics := string(exporter.metrics(updateTime))
+	for _, expected := range []string{
+		"monidash_up 0\n",
+		"monidash_network_errors_total 1\n",
+		"monidash_last_update_timestamp_seconds 1425590900\n",
+		"monidash_builds{state=\"failed\"} 1\n",
+		"monidash_build_state{build=\"payments-api\",state=\"failed\"} 1\n",
+	} {
+		assert.Contains(t, metrics, expected)
+	}
+}
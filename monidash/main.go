@@ -1,10 +1,19 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"github.com/codegangsta/cli"
 	md "github.com/samuelrayment/monitrondashboard"
+	"github.com/samuelrayment/monitrondashboard/fakemonitron"
+	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
@@ -13,23 +22,594 @@ func main() {
 	app.Usage = "Terminal based dashboard for the Monitron 5000"
 	app.Version = "0.1.0"
 	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "config, c",
+			Usage:  "Config file to load, defaults to monidash/config.yaml in $XDG_CONFIG_HOME or ~/.config.",
+			EnvVar: "MD_CONFIG",
+		},
+		cli.StringFlag{
+			Name:   "profile, p",
+			Usage:  "Profile from the config file to use.",
+			EnvVar: "MD_PROFILE",
+		},
 		cli.StringFlag{
 			Name:   "address, a",
-			Usage:  "Address for the Monitron server to connect to.",
+			Usage:  "Address for the Monitron server to connect to, instead of the servers in the config file.",
 			EnvVar: "MD_ADDRESS",
 		},
+		cli.StringFlag{
+			Name:   "output, o",
+			Value:  "dashboard",
+			Usage:  "Output mode: dashboard, text (a line per change), status (a one line summary) or json (a line per update).",
+			EnvVar: "MD_OUTPUT",
+		},
+		cli.StringFlag{
+			Name:   "theme",
+			Usage:  "Theme for the dashboard: default, high-contrast, colour-blind, solarized, light-background or one from the config file.",
+			EnvVar: "MD_THEME",
+		},
+		cli.StringFlag{
+			Name:   "colours",
+			Usage:  "Colours the terminal can show: auto, truecolour, 256, 16, 8 or monochrome. auto detects them from TERM, COLORTERM and NO_COLOR.",
+			EnvVar: "MD_COLOURS",
+		},
+		cli.StringFlag{
+			Name:   "backend",
+			Usage:  "Terminal library to draw the dashboard with: termbox or tcell.",
+			EnvVar: "MD_BACKEND",
+		},
+		cli.StringFlag{
+			Name:   "metrics-address",
+			Usage:  "Serve Prometheus metrics on this address, e.g. :9100.",
+			EnvVar: "MD_METRICS_ADDRESS",
+		},
+		cli.StringFlag{
+			Name:  "record",
+			Usage: "Record every line received from the server to this file, for playing back with replay.",
+		},
+		cli.StringFlag{
+			Name:   "on-transition",
+			Usage:  "Shell command to run when a build fails or recovers, details are in MD_ environment variables.",
+			EnvVar: "MD_ON_TRANSITION",
+		},
+		cli.StringFlag{
+			Name:   "notify-command",
+			Usage:  "notify-send style command to run with a title and message when a build fails or recovers.",
+			EnvVar: "MD_NOTIFY_COMMAND",
+		},
+		cli.StringSliceFlag{
+			Name:  "webhook",
+			Usage: "URL to POST build state changes to, prefix with slack=, mattermost= or teams= for chat formatted messages.",
+		},
+		cli.DurationFlag{
+			Name:  "webhook-debounce",
+			Value: 30 * time.Second,
+			Usage: "How long a build must stay in a state before webhooks are sent.",
+		},
+		cli.DurationFlag{
+			Name:   "escalate-after",
+			Usage:  "Escalate builds that have been failing without being acknowledged for this long, e.g. 30m.",
+			EnvVar: "MD_ESCALATE_AFTER",
+		},
+		cli.StringFlag{
+			Name:   "on-escalation",
+			Usage:  "Shell command to run when a build is escalated, details are in MD_ environment variables.",
+			EnvVar: "MD_ON_ESCALATION",
+		},
+		cli.BoolFlag{
+			Name:  "bell",
+			Usage: "Ring the terminal bell when a build fails or recovers.",
+		},
+		cli.BoolFlag{
+			Name:  "tmux",
+			Usage: "Add tmux colour codes to the status output.",
+		},
+		cli.DurationFlag{
+			Name:  "interval",
+			Usage: "Print the status output at this interval, e.g. 30s, rather than on every update.",
+		},
 	}
 	app.Action = mainAppAction
+	app.Commands = []cli.Command{
+		{
+			Name:  "serve",
+			Usage: "Serve the dashboard to web browsers over HTTP.",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:   "listen, l",
+					Value:  ":8080",
+					Usage:  "Address to serve the web dashboard on.",
+					EnvVar: "MD_LISTEN",
+				},
+				cli.BoolFlag{
+					Name:  "dashboard, d",
+					Usage: "Also show the terminal dashboard.",
+				},
+			},
+			Action: serveAction,
+		},
+		{
+			Name:      "replay",
+			Usage:     "Play back a recording made with --record.",
+			ArgsUsage: "<recording>",
+			Flags: []cli.Flag{
+				cli.Float64Flag{
+					Name:  "speed, s",
+					Value: 1,
+					Usage: "Play back at this many times the recorded speed.",
+				},
+			},
+			Action: replayAction,
+		},
+		{
+			Name:  "fake-server",
+			Usage: "Run a fake Monitron server for development and testing.",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "listen, l",
+					Value: ":9988",
+					Usage: "Address to accept dashboard connections on.",
+				},
+				cli.IntFlag{
+					Name:  "builds",
+					Value: fakemonitron.DefaultScenario.Builds,
+					Usage: "Number of builds.",
+				},
+				cli.DurationFlag{
+					Name:  "interval",
+					Value: fakemonitron.DefaultScenario.Interval,
+					Usage: "Time between updates.",
+				},
+				cli.Float64Flag{
+					Name:  "failure-rate",
+					Value: fakemonitron.DefaultScenario.FailureRate,
+					Usage: "Chance a build starts or stops failing each update.",
+				},
+				cli.Float64Flag{
+					Name:  "acknowledge-rate",
+					Value: fakemonitron.DefaultScenario.AcknowledgeRate,
+					Usage: "Chance a failing build is acknowledged each update.",
+				},
+				cli.Float64Flag{
+					Name:  "building-rate",
+					Value: fakemonitron.DefaultScenario.BuildingRate,
+					Usage: "Chance a build starts or stops building each update.",
+				},
+				cli.Float64Flag{
+					Name:  "malformed-rate",
+					Usage: "Chance an update is sent as a malformed line.",
+				},
+				cli.Float64Flag{
+					Name:  "disconnect-rate",
+					Usage: "Chance a dashboard is disconnected after each update.",
+				},
+				cli.BoolFlag{
+					Name:  "split-writes",
+					Usage: "Send each line in several small writes.",
+				},
+				cli.Int64Flag{
+					Name:  "seed",
+					Value: fakemonitron.DefaultScenario.Seed,
+					Usage: "Seed for the random changes, the same seed gives the same changes.",
+				},
+			},
+			Action: fakeServerAction,
+		},
+		{
+			Name:  "relay",
+			Usage: "Relay builds from the server to other dashboards over one connection.",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:   "listen, l",
+					Value:  ":9988",
+					Usage:  "Address to accept dashboard connections on.",
+					EnvVar: "MD_LISTEN",
+				},
+			},
+			Action: relayAction,
+		},
+	}
 	app.Run(os.Args)
 }
 
 func mainAppAction(c *cli.Context) {
-	if c.String("address") == "" {
+	settings, err := loadSettings(c)
+	if err != nil {
+		log.Printf("Error: %s", err)
+		return
+	}
+	if len(settings.Servers) == 0 {
 		log.Printf("You must provide the address of a server to connect to.")
 		return
 	}
 
-	fetcher := md.NewBuildFetcher(c.String("address"))
-	dashboard := md.NewDashboard(fetcher, md.TermboxCellDrawer{})
-	dashboard.Run()
+	fetcher, reload, closeRecording, err := newFetcher(c, settings)
+	if err != nil {
+		log.Printf("Error: %s", err)
+		return
+	}
+	defer closeRecording()
+	runOutput(c, settings, fetcher, reload)
+}
+
+// configPath returns the path of the config file to load, or an empty
+// string if there isn't one.
+func configPath(c *cli.Context) string {
+	if c.GlobalString("config") != "" {
+		return c.GlobalString("config")
+	}
+	if _, err := os.Stat(md.DefaultConfigPath()); err == nil {
+		return md.DefaultConfigPath()
+	}
+	return ""
+}
+
+// loadSettings loads the settings from the config file and profile, if
+// there are any, then applies the flags given on the command line.
+func loadSettings(c *cli.Context) (md.Settings, error) {
+	settings := md.DefaultSettings()
+	if path := configPath(c); path != "" {
+		var err error
+		if settings, err = md.LoadConfig(path, c.GlobalString("profile")); err != nil {
+			return settings, err
+		}
+	} else if c.GlobalString("profile") != "" {
+		return settings, errors.New("A profile can only be used with a config file")
+	}
+	return applyFlags(c, settings)
+}
+
+// applyFlags changes settings to match the flags given on the command
+// line, which take precedence over the config file.
+func applyFlags(c *cli.Context, settings md.Settings) (md.Settings, error) {
+	if c.GlobalIsSet("address") {
+		address := c.GlobalString("address")
+		settings.Servers = []md.ServerConfig{{Name: address, Address: address}}
+	}
+	if c.GlobalIsSet("output") {
+		settings.Output = md.OutputMode(c.GlobalString("output"))
+	}
+	if c.GlobalIsSet("theme") {
+		if _, ok := settings.LookupTheme(c.GlobalString("theme")); !ok {
+			return settings, fmt.Errorf("Unknown theme: %s", c.GlobalString("theme"))
+		}
+		settings.Theme = c.GlobalString("theme")
+	}
+	if c.GlobalIsSet("colours") {
+		colours, err := md.ParseColourMode(c.GlobalString("colours"))
+		if err != nil {
+			return settings, err
+		}
+		settings.Colours = colours
+	}
+	if c.GlobalIsSet("backend") {
+		backend, err := md.ParseBackend(c.GlobalString("backend"))
+		if err != nil {
+			return settings, err
+		}
+		settings.Backend = backend
+	}
+	if c.GlobalIsSet("metrics-address") {
+		settings.MetricsAddress = c.GlobalString("metrics-address")
+	}
+	if c.GlobalIsSet("tmux") {
+		settings.Status.Tmux = c.GlobalBool("tmux")
+	}
+	if c.GlobalIsSet("interval") {
+		settings.Status.Interval = c.GlobalDuration("interval")
+	}
+
+	notifications := &settings.Notifications
+	if c.GlobalIsSet("on-transition") {
+		notifications.OnTransition = c.GlobalString("on-transition")
+	}
+	if c.GlobalIsSet("notify-command") {
+		notifications.NotifyCommand = c.GlobalString("notify-command")
+	}
+	if c.GlobalIsSet("bell") {
+		notifications.Bell = c.GlobalBool("bell")
+	}
+	if c.GlobalIsSet("webhook") {
+		notifications.Webhooks = nil
+		for _, webhookFlag := range c.GlobalStringSlice("webhook") {
+			webhook, err := md.ParseWebhook(webhookFlag)
+			if err != nil {
+				return settings, err
+			}
+			notifications.Webhooks = append(notifications.Webhooks, webhook)
+		}
+	}
+	if c.GlobalIsSet("webhook-debounce") {
+		notifications.WebhookDebounce = c.GlobalDuration("webhook-debounce")
+	}
+	if c.GlobalIsSet("escalate-after") {
+		notifications.EscalateAfter = c.GlobalDuration("escalate-after")
+	}
+	if c.GlobalIsSet("on-escalation") {
+		notifications.OnEscalation = c.GlobalString("on-escalation")
+	}
+	return settings, nil
+}
+
+// runOutput shows the builds from fetcher using the output mode chosen
+// in the settings, calling reload when the config file is reloaded.
+func runOutput(c *cli.Context, settings md.Settings, fetcher md.BuildFetcher,
+	reload func(md.Settings)) {
+	var printer interface {
+		Run() error
+	}
+	switch settings.Output {
+	case "dashboard":
+		runDashboard(c, settings, fetcher, reload)
+		return
+	case "text":
+		printer = md.NewTextPrinter(fetcher, os.Stdout)
+	case "status":
+		printer = md.NewStatusPrinter(fetcher, os.Stdout, settings.Status.Tmux,
+			settings.Status.Interval, settings.CurrentTheme())
+	case "json":
+		printer = md.NewJSONPrinter(fetcher, os.Stdout)
+	default:
+		log.Printf("Unknown output mode: %s", settings.Output)
+		return
+	}
+
+	go watchConfig(c, reload, nil)
+	if err := printer.Run(); err != nil {
+		log.Printf("Error writing output: %s", err)
+	}
+}
+
+// runDashboard shows the builds from fetcher on the terminal dashboard,
+// which picks up changes to the config file as it runs.
+func runDashboard(c *cli.Context, settings md.Settings, fetcher md.BuildFetcher,
+	reload func(md.Settings)) {
+	dashboard := md.NewDashboard(fetcher, md.NewFrameBuffer(md.NewScreen(settings.Backend)))
+	dashboard.ApplySettings(settings)
+	configChannel := make(chan md.ConfigUpdate)
+	dashboard.SetConfigChannel(configChannel)
+	go watchConfig(c, reload, configChannel)
+	// Anything logged while the dashboard owns the terminal, such as a
+	// notification hook failing, is shown on the dashboard instead.
+	log.SetOutput(dashboard.LogWriter())
+	err := dashboard.Run()
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		log.Printf("Error: %s", err)
+	}
+}
+
+// watchConfig reloads the config file when it changes or the process is
+// sent SIGHUP, applying the new settings with reload. The updates are
+// sent on to configChannel if it isn't nil, otherwise errors are logged.
+func watchConfig(c *cli.Context, reload func(md.Settings),
+	configChannel chan md.ConfigUpdate) {
+	path := configPath(c)
+	if path == "" {
+		return
+	}
+	watcher := md.NewConfigWatcher(path, c.GlobalString("profile"))
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	go func() {
+		for range hangups {
+			watcher.Reload()
+		}
+	}()
+
+	for update := range watcher.UpdateChannel() {
+		if update.Err == nil {
+			update.Settings, update.Err = applyFlags(c, update.Settings)
+		}
+		if update.Err == nil {
+			reload(update.Settings)
+		}
+		if configChannel != nil {
+			configChannel <- update
+		} else if update.Err != nil {
+			log.Printf("Error reloading config: %s", update.Err)
+		}
+	}
+}
+
+// replayAction plays back a recording using the chosen output mode.
+func replayAction(c *cli.Context) {
+	if len(c.Args()) != 1 {
+		log.Printf("You must provide the recording to play back.")
+		return
+	}
+	if c.Float64("speed") <= 0 {
+		log.Printf("The speed must be greater than zero.")
+		return
+	}
+	settings, err := loadSettings(c)
+	if err != nil {
+		log.Printf("Error: %s", err)
+		return
+	}
+
+	recording, err := os.Open(c.Args().First())
+	if err != nil {
+		log.Printf("Error opening recording: %s", err)
+		return
+	}
+	defer recording.Close()
+
+	replay := md.NewReplayBuildFetcher(recording, c.Float64("speed"))
+	view := md.NewViewFetcher(withNotifier(settings, replay), settings.View)
+	runOutput(c, settings, playbackFetcher{view, replay}, func(settings md.Settings) {
+		view.SetView(settings.View)
+	})
+}
+
+// playbackFetcher is the last of the fetchers wrapping a replay, given
+// the replay's controls so the dashboard can still pause and step it.
+type playbackFetcher struct {
+	md.BuildFetcher
+	md.PlaybackController
+}
+
+// serveAction serves the web dashboard, optionally alongside the
+// terminal dashboard using the same connection to the server.
+func serveAction(c *cli.Context) {
+	settings, err := loadSettings(c)
+	if err != nil {
+		log.Printf("Error: %s", err)
+		return
+	}
+	if len(settings.Servers) == 0 {
+		log.Printf("You must provide the address of a server to connect to.")
+		return
+	}
+
+	fetcher, reload, closeRecording, err := newFetcher(c, settings)
+	if err != nil {
+		log.Printf("Error: %s", err)
+		return
+	}
+	defer closeRecording()
+	server := md.NewWebServer(fetcher, settings.CurrentTheme())
+	go func() {
+		if err := http.ListenAndServe(c.String("listen"), server); err != nil {
+			log.Fatalf("Error serving web dashboard: %s", err)
+		}
+	}()
+
+	if c.Bool("dashboard") {
+		runDashboard(c, settings, server, reload)
+		return
+	}
+	go watchConfig(c, reload, nil)
+	for range server.BuildChannel() {
+	}
+}
+
+// relayAction re-serves the builds from the server to any dashboards
+// that connect.
+func relayAction(c *cli.Context) {
+	settings, err := loadSettings(c)
+	if err != nil {
+		log.Printf("Error: %s", err)
+		return
+	}
+	if len(settings.Servers) == 0 {
+		log.Printf("You must provide the address of a server to connect to.")
+		return
+	}
+
+	listener, err := net.Listen("tcp", c.String("listen"))
+	if err != nil {
+		log.Printf("Error listening for dashboards: %s", err)
+		return
+	}
+	fetcher, reload, closeRecording, err := newFetcher(c, settings)
+	if err != nil {
+		log.Printf("Error: %s", err)
+		return
+	}
+	defer closeRecording()
+	relay := md.NewRelay(fetcher)
+	go relay.Run()
+	go watchConfig(c, reload, nil)
+	if err := relay.Serve(listener); err != nil {
+		log.Printf("Error accepting dashboards: %s", err)
+	}
+}
+
+// fakeServerAction runs a fake Monitron server.
+func fakeServerAction(c *cli.Context) {
+	server := fakemonitron.NewServer(fakemonitron.Scenario{
+		Builds:          c.Int("builds"),
+		Interval:        c.Duration("interval"),
+		FailureRate:     c.Float64("failure-rate"),
+		AcknowledgeRate: c.Float64("acknowledge-rate"),
+		BuildingRate:    c.Float64("building-rate"),
+		MalformedRate:   c.Float64("malformed-rate"),
+		DisconnectRate:  c.Float64("disconnect-rate"),
+		SplitWrites:     c.Bool("split-writes"),
+		Seed:            c.Int64("seed"),
+	})
+	if err := server.ListenAndServe(c.String("listen")); err != nil {
+		log.Printf("Error serving: %s", err)
+	}
+}
+
+// newFetcher creates a BuildFetcher for the servers in settings,
+// recording what it receives and serving its metrics if asked to. The
+// first returned function applies reloaded settings to the fetcher, only
+// reconnecting if the servers have changed, and the second closes the
+// recording.
+func newFetcher(c *cli.Context, settings md.Settings) (md.BuildFetcher,
+	func(md.Settings), func(), error) {
+	var recording io.Writer
+	closeRecording := func() {}
+	if c.GlobalString("record") != "" {
+		if len(settings.Servers) > 1 {
+			return nil, nil, nil, errors.New("Only a single server can be recorded")
+		}
+		file, err := os.Create(c.GlobalString("record"))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		recording = file
+		closeRecording = func() {
+			if err := file.Close(); err != nil {
+				log.Printf("Error closing recording: %s", err)
+			}
+		}
+	}
+
+	servers := md.NewServerBuildFetcher(settings.Servers, recording)
+	var fetcher md.BuildFetcher = servers
+	if settings.MetricsAddress != "" {
+		exporter := md.NewMetricsExporter(fetcher)
+		fetcher = exporter
+		go serveMetrics(settings.MetricsAddress, exporter)
+	}
+	// Builds are notified and escalated before the view filters them, so
+	// builds that aren't shown, or have just left the view by recovering,
+	// are still noticed.
+	view := md.NewViewFetcher(withNotifier(settings, fetcher), settings.View)
+	reload := func(settings md.Settings) {
+		if len(settings.Servers) > 0 {
+			servers.SetServers(settings.Servers)
+		}
+		view.SetView(settings.View)
+	}
+	return view, reload, closeRecording, nil
+}
+
+// withNotifier wraps fetcher in a Notifier if any notification hooks
+// were asked for, and an Escalator if escalation was.
+func withNotifier(settings md.Settings, fetcher md.BuildFetcher) md.BuildFetcher {
+	notifications := settings.Notifications
+	hooks := md.NotificationHooks{
+		Command:         notifications.OnTransition,
+		NotifyCommand:   notifications.NotifyCommand,
+		Webhooks:        notifications.Webhooks,
+		WebhookDebounce: notifications.WebhookDebounce,
+		Theme:           settings.CurrentTheme(),
+	}
+	if notifications.Bell {
+		hooks.Bell = os.Stderr
+	}
+
+	if hooks.Command != "" || hooks.NotifyCommand != "" || hooks.Bell != nil ||
+		len(hooks.Webhooks) > 0 {
+		fetcher = md.NewNotifier(fetcher, hooks)
+	}
+	if notifications.EscalateAfter > 0 {
+		fetcher = md.NewEscalator(fetcher, notifications.EscalateAfter,
+			notifications.OnEscalation)
+	}
+	return fetcher
+}
+
+// serveMetrics serves the exporter's metrics on /metrics at address.
+func serveMetrics(address string, exporter *md.MetricsExporter) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	if err := http.ListenAndServe(address, mux); err != nil {
+		log.Printf("Error serving metrics: %s", err)
+	}
 }
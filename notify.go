@@ -0,0 +1,156 @@
+package monitrondashboard
+
+// Notifications for the monitron dashboard.
+// Here you'll find code for running hooks when a build starts failing or
+// recovers, so people don't have to keep watching the dashboard.
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// NotificationHooks configures what a Notifier does for each transition.
+type NotificationHooks struct {
+	// Command is run with sh -c, with details of the build in MD_
+	// environment variables.
+	Command string
+	// NotifyCommand is a notify-send style command, it is run with a
+	// title and a message as its arguments.
+	NotifyCommand string
+	// Bell, if not nil, has a terminal bell written to it.
+	Bell io.Writer
+	// Webhooks are sent every change in a build's state, once the build
+	// has stayed the same for WebhookDebounce.
+	Webhooks        []Webhook
+	WebhookDebounce time.Duration
+	// Theme colours the webhook messages that have colours.
+	Theme Theme
+}
+
+// Notifier is a BuildFetcher that wraps another BuildFetcher, running
+// its hooks whenever a build starts failing or recovers before passing
+// each BuildUpdate on.
+type Notifier struct {
+	fetcher      BuildFetcher
+	buildChannel chan BuildUpdate
+	hooks        NotificationHooks
+	builds       []build
+	started      bool
+	runCommand   func(command *exec.Cmd) error
+	running      sync.WaitGroup
+	webhooks     *webhookSender
+}
+
+// NewNotifier creates a Notifier running hooks for the updates from
+// fetcher.
+func NewNotifier(fetcher BuildFetcher, hooks NotificationHooks) *Notifier {
+	notifier := &Notifier{
+		fetcher:      fetcher,
+		buildChannel: make(chan BuildUpdate),
+		hooks:        hooks,
+		builds:       []build{},
+		runCommand:   (*exec.Cmd).Run,
+	}
+	if len(hooks.Webhooks) > 0 {
+		notifier.webhooks = newWebhookSender(hooks.Webhooks, hooks.WebhookDebounce,
+			hooks.Theme, SystemClock)
+	}
+	go notifier.forwardBuilds()
+	return notifier
+}
+
+func (n *Notifier) BuildChannel() chan BuildUpdate {
+	return n.buildChannel
+}
+
+// forwardBuilds notifies the changes in every update from the wrapped
+// fetcher and passes it on. Hooks run in the background, but are waited
+// for before the channel is closed.
+func (n *Notifier) forwardBuilds() {
+	for buildUpdate := range n.fetcher.BuildChannel() {
+		changes := n.changes(buildUpdate)
+		if n.webhooks != nil {
+			for _, change := range changes {
+				n.webhooks.change(change)
+			}
+		}
+		for _, transition := range buildTransitions(changes) {
+			n.running.Add(1)
+			go func(transition buildTransition) {
+				defer n.running.Done()
+				n.notify(transition)
+			}(transition)
+		}
+		n.buildChannel <- buildUpdate
+	}
+	n.running.Wait()
+	if n.webhooks != nil {
+		n.webhooks.close()
+	}
+	close(n.buildChannel)
+}
+
+// changes returns the changes between the last good update and
+// buildUpdate. The first update is only used as a starting point, so
+// starting the dashboard doesn't notify every failing build. An update
+// with an error only changes the builds it has.
+func (n *Notifier) changes(buildUpdate BuildUpdate) []buildChange {
+	builds := buildUpdate.builds
+	if buildUpdate.err != nil {
+		if len(builds) == 0 {
+			return []buildChange{}
+		}
+		builds = withMissingBuilds(n.builds, builds)
+	}
+	changes := []buildChange{}
+	if n.started {
+		changes = diffBuilds(n.builds, builds)
+	}
+	n.builds = builds
+	n.started = true
+	return changes
+}
+
+// notify runs the hooks for a transition.
+func (n *Notifier) notify(transition buildTransition) {
+	if n.hooks.Bell != nil {
+		n.hooks.Bell.Write([]byte("\a"))
+	}
+
+	if n.hooks.Command != "" {
+		command := exec.Command("sh", "-c", n.hooks.Command)
+		command.Env = append(os.Environ(), transitionEnvironment(transition)...)
+		if err := n.runCommand(command); err != nil {
+			log.Printf("Error running notification command: %s", err)
+		}
+	}
+
+	if n.hooks.NotifyCommand != "" {
+		command := exec.Command(n.hooks.NotifyCommand,
+			fmt.Sprintf("%s %s", transition.change.name(), transition.kind),
+			describeBuildChange(transition.change))
+		if err := n.runCommand(command); err != nil {
+			log.Printf("Error running notify command: %s", err)
+		}
+	}
+}
+
+// transitionEnvironment returns the environment variables describing
+// a transition for the notification command.
+func transitionEnvironment(transition buildTransition) []string {
+	current := transition.change.current
+	return []string{
+		"MD_TRANSITION=" + transition.kind.String(),
+		"MD_BUILD_NAME=" + current.name,
+		"MD_BUILD_STATE=" + current.buildState.String(),
+		"MD_BUILD_PREVIOUS_STATE=" + transition.change.previous.buildState.String(),
+		"MD_BUILD_ACKNOWLEDGER=" + current.acknowledger,
+		"MD_BUILD_BUILDING=" + strconv.FormatBool(current.building),
+	}
+}
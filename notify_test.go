@@ -0,0 +1,92 @@
+package monitrondashboard
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"os/exec"
+	"testing"
+)
+
+var notifyTestTransition = buildTransition{
+	buildTransitionFailed,
+	buildChange{
+		buildChanged,
+		build{name: "payments-api", buildState: BuildStatePassed},
+		build{name: "payments-api", buildState: BuildStateFailed, building: true},
+	},
+}
+
+func TestNotifierOnlyNotifiesTransitionsAfterTheFirstUpdate(t *testing.T) {
+	notifier := &Notifier{}
+
+	changes := notifier.changes(BuildUpdate{builds: []build{
+		{name: "payments-api", buildState: BuildStateFailed},
+	}})
+	assert.Empty(t, changes, "Builds failing at start up should not be notified")
+
+	notifier.changes(BuildUpdate{err: buildError{BuildErrorNetwork, "Network Error"}})
+	transitions := buildTransitions(notifier.changes(BuildUpdate{builds: []build{
+		{name: "payments-api", buildState: BuildStatePassed},
+	}}))
+	if assert.Len(t, transitions, 1) {
+		assert.Equal(t, buildTransitionRecovered, transitions[0].kind)
+	}
+}
+
+func TestNotifierRunsHooks(t *testing.T) {
+	var bell bytes.Buffer
+	commands := []*exec.Cmd{}
+	notifier := &Notifier{
+		hooks: NotificationHooks{
+			Command:       "./on-transition.sh",
+			NotifyCommand: "notify-send",
+			Bell:          &bell,
+		},
+		runCommand: func(command *exec.Cmd) error {
+			commands = append(commands, command)
+			return nil
+		},
+	}
+
+	notifier.notify(notifyTestTransition)
+
+	assert.Equal(t, "\a", bell.String())
+	if assert.Len(t, commands, 2) {
+		assert.Equal(t, []string{"sh", "-c", "./on-transition.sh"}, commands[0].Args)
+		assert.Subset(t, commands[0].Env, []string{
+			"MD_TRANSITION=failed",
+			"MD_BUILD_NAME=payments-api",
+			"MD_BUILD_STATE=failed",
+			"MD_BUILD_PREVIOUS_STATE=passed",
+			"MD_BUILD_ACKNOWLEDGER=",
+			"MD_BUILD_BUILDING=true",
+		})
+		assert.Equal(t, []string{"notify-send", "payments-api failed",
+			"payments-api: passed → FAILED (building)"}, commands[1].Args)
+	}
+}
+
+func TestNotifierNotifiesTheBuildsOfWorkingServersWhenAnotherFails(t *testing.T) {
+	notifier := &Notifier{}
+	notifier.changes(BuildUpdate{builds: []build{
+		{name: "payments-api", buildState: BuildStatePassed, source: "ci"},
+		{name: "search", buildState: BuildStateFailed, source: "release"},
+	}})
+
+	transitions := buildTransitions(notifier.changes(BuildUpdate{
+		builds: []build{
+			{name: "payments-api", buildState: BuildStateFailed, source: "ci"},
+		},
+		err: buildError{BuildErrorNetwork, "release: Network Error"},
+	}))
+	if assert.Len(t, transitions, 1) {
+		assert.Equal(t, "payments-api", transitions[0].change.name())
+	}
+
+	transitions = buildTransitions(notifier.changes(BuildUpdate{builds: []build{
+		{name: "payments-api", buildState: BuildStateFailed, source: "ci"},
+		{name: "search", buildState: BuildStateFailed, source: "release"},
+	}}))
+	assert.Empty(t, transitions,
+		"Builds of a server that reconnects should not be notified again")
+}
@@ -0,0 +1,178 @@
+package monitrondashboard
+
+// Recording and replay for the monitron dashboard.
+// Here you'll find code for recording the lines received from a Monitron
+// server and a BuildFetcher that plays them back.
+//
+// A recording has a line for every line received, holding the time it was
+// received in RFC 3339 format and the line itself, separated by a tab.
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recorder writes received lines to a recording.
+type recorder struct {
+	writer io.Writer
+}
+
+// record writes line, received at receivedAt, to the recording.
+func (r *recorder) record(line string, receivedAt time.Time) error {
+	line = strings.TrimRight(line, "\n")
+	_, err := fmt.Fprintf(r.writer, "%s\t%s\n",
+		receivedAt.Format(time.RFC3339Nano), line)
+	return err
+}
+
+// recordedLine is a single line read back from a recording.
+type recordedLine struct {
+	receivedAt time.Time
+	line       string
+}
+
+// parseRecordedLine parses a line from a recording.
+func parseRecordedLine(recording string) (recordedLine, error) {
+	parts := strings.SplitN(strings.TrimRight(recording, "\n"), "\t", 2)
+	if len(parts) != 2 {
+		return recordedLine{}, errors.New("Recorded line has no timestamp")
+	}
+	receivedAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return recordedLine{}, err
+	}
+	return recordedLine{receivedAt, parts[1]}, nil
+}
+
+// A PlaybackController is a BuildFetcher that can be paused and stepped
+// through, such as a ReplayBuildFetcher.
+type PlaybackController interface {
+	// TogglePause pauses a playing fetcher or resumes a paused one.
+	TogglePause()
+	// Step sends the next update straight away.
+	Step()
+}
+
+// ReplayBuildFetcher is a BuildFetcher that plays back a recording,
+// waiting between updates for as long as the server did divided by a
+// speed up factor. Its channel is closed at the end of the recording.
+type ReplayBuildFetcher struct {
+	reader       *bufio.Reader
+	speed        float64
+	clock        Clock
+	buildChannel chan BuildUpdate
+
+	mutex  sync.Mutex
+	paused bool
+	steps  int
+	wake   chan bool
+}
+
+// NewReplayBuildFetcher creates a ReplayBuildFetcher playing back
+// recording at speed times the speed it was recorded at.
+func NewReplayBuildFetcher(recording io.Reader, speed float64) *ReplayBuildFetcher {
+	return newReplayBuildFetcher(recording, speed, SystemClock)
+}
+
+// newReplayBuildFetcher creates a ReplayBuildFetcher that waits between
+// updates using clock.
+func newReplayBuildFetcher(recording io.Reader, speed float64,
+	clock Clock) *ReplayBuildFetcher {
+	replay := &ReplayBuildFetcher{
+		reader:       bufio.NewReader(recording),
+		speed:        speed,
+		clock:        clock,
+		buildChannel: make(chan BuildUpdate),
+		wake:         make(chan bool, 1),
+	}
+	go replay.replay()
+	return replay
+}
+
+func (r *ReplayBuildFetcher) BuildChannel() chan BuildUpdate {
+	return r.buildChannel
+}
+
+func (r *ReplayBuildFetcher) TogglePause() {
+	r.mutex.Lock()
+	r.paused = !r.paused
+	r.mutex.Unlock()
+	r.signal()
+}
+
+func (r *ReplayBuildFetcher) Step() {
+	r.mutex.Lock()
+	r.steps++
+	r.mutex.Unlock()
+	r.signal()
+}
+
+// signal wakes the replay go routine if it is waiting.
+func (r *ReplayBuildFetcher) signal() {
+	select {
+	case r.wake <- true:
+	default:
+	}
+}
+
+// replay sends an update for every line in the recording.
+func (r *ReplayBuildFetcher) replay() {
+	defer close(r.buildChannel)
+
+	var previous time.Time
+	for {
+		recording, err := r.reader.ReadString('\n')
+		if recording == "" && err != nil {
+			return
+		}
+		recorded, parseErr := parseRecordedLine(recording)
+		if parseErr != nil {
+			r.buildChannel <- BuildUpdate{
+				builds: []build{},
+				err: buildError{BuildErrorParse,
+					fmt.Sprintf("Cannot Parse Recording: %s", parseErr)},
+			}
+			continue
+		}
+
+		if !previous.IsZero() {
+			r.wait(time.Duration(
+				float64(recorded.receivedAt.Sub(previous)) / r.speed))
+		}
+		previous = recorded.receivedAt
+		r.buildChannel <- parseBuildUpdate(recorded.line)
+	}
+}
+
+// wait waits for duration, not counting the time spent paused, or until
+// the next step.
+func (r *ReplayBuildFetcher) wait(duration time.Duration) {
+	for {
+		r.mutex.Lock()
+		paused := r.paused
+		stepped := r.steps > 0
+		if stepped {
+			r.steps--
+		}
+		r.mutex.Unlock()
+
+		if stepped || (!paused && duration <= 0) {
+			return
+		}
+		if paused {
+			<-r.wake
+			continue
+		}
+
+		started := r.clock.Now()
+		timer := r.clock.AfterFunc(duration, r.signal)
+		<-r.wake
+		timer.Stop()
+		duration -= r.clock.Now().Sub(started)
+	}
+}
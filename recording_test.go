@@ -0,0 +1,80 @@
+package monitrondashboard
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorderWritesTimestampedLines(t *testing.T) {
+	var buffer bytes.Buffer
+	recorder := recorder{writer: &buffer}
+
+	recorder.record(testData+"\n", time.Date(2015, 3, 5, 21, 27, 8, 0, time.UTC))
+
+	assert.Equal(t, "2015-03-05T21:27:08Z\t"+testData+"\n", buffer.String())
+	recorded, err := parseRecordedLine(buffer.String())
+	assert.NoError(t, err)
+	assert.Equal(t, testData, recorded.line)
+}
+
+func TestReplayBuildFetcherPlaysBackARecording(t *testing.T) {
+	recording := "2015-03-05T21:27:08Z\t" + testData + "\n" +
+		"2015-03-05T21:27:09Z\tnot json\n" +
+		"no timestamp\n"
+	replay := NewReplayBuildFetcher(strings.NewReader(recording), 1000)
+
+	buildUpdate := <-replay.BuildChannel()
+	assert.NoError(t, buildUpdate.err)
+	assert.Len(t, buildUpdate.builds, 2)
+
+	buildUpdate = <-replay.BuildChannel()
+	assert.Equal(t, "parse", errorKindName(buildUpdate.err))
+
+	buildUpdate = <-replay.BuildChannel()
+	assert.EqualError(t, buildUpdate.err,
+		"Cannot Parse Recording: Recorded line has no timestamp")
+
+	_, ok := <-replay.BuildChannel()
+	assert.False(t, ok, "The channel should be closed at the end of the recording")
+}
+
+func TestReplayBuildFetcherWaitsAsLongAsTheServerDid(t *testing.T) {
+	recording := "2015-03-05T21:27:08Z\t" + testData + "\n" +
+		"2015-03-05T22:27:08Z\t" + testData + "\n"
+	clock := NewFakeClock(time.Unix(1425590828, 0))
+	replay := newReplayBuildFetcher(strings.NewReader(recording), 2, clock)
+	<-replay.BuildChannel()
+
+	waitForWaiters(t, clock, 1)
+	clock.Advance(29 * time.Minute)
+	select {
+	case <-replay.BuildChannel():
+		t.Fatalf("The next update should wait half an hour at double speed")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.Advance(time.Minute)
+	buildUpdate := <-replay.BuildChannel()
+	assert.NoError(t, buildUpdate.err)
+}
+
+func TestReplayBuildFetcherCanBePausedAndStepped(t *testing.T) {
+	recording := "2015-03-05T21:27:08Z\t" + testData + "\n" +
+		"2015-03-05T22:27:08Z\t" + testData + "\n"
+	replay := NewReplayBuildFetcher(strings.NewReader(recording), 1)
+	<-replay.BuildChannel()
+	replay.TogglePause()
+
+	select {
+	case <-replay.BuildChannel():
+		t.Fatalf("A paused replay should not send updates")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	replay.Step()
+	buildUpdate := <-replay.BuildChannel()
+	assert.NoError(t, buildUpdate.err)
+}
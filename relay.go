@@ -0,0 +1,101 @@
+package monitrondashboard
+
+// Relay server for the monitron dashboard.
+// Here you'll find code for re-serving the builds from a single
+// connection to a Monitron server to any number of dashboards using the
+// same newline delimited JSON protocol.
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+)
+
+// relayWriteTimeout is how long a write to a downstream client may take
+// before the client is dropped.
+const relayWriteTimeout = 10 * time.Second
+
+// Relay serves every BuildUpdate from a BuildFetcher to the clients
+// connected to it, sending the latest update to clients as they connect.
+type Relay struct {
+	fetcher BuildFetcher
+
+	mutex   sync.Mutex
+	latest  []byte
+	clients map[chan []byte]bool
+}
+
+// NewRelay creates a Relay serving the updates from fetcher.
+func NewRelay(fetcher BuildFetcher) *Relay {
+	return &Relay{
+		fetcher: fetcher,
+		clients: map[chan []byte]bool{},
+	}
+}
+
+// Run sends build updates to the connected clients as they arrive.
+func (r *Relay) Run() {
+	for buildUpdate := range r.fetcher.BuildChannel() {
+		r.broadcast(buildUpdate)
+	}
+}
+
+// Serve accepts clients on listener, returning when accepting fails.
+func (r *Relay) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go r.serveClient(conn)
+	}
+}
+
+// broadcast encodes buildUpdate in the Monitron protocol and sends it to
+// every client. Each line is a full snapshot so a client that hasn't
+// been sent the last one yet only needs the newest.
+func (r *Relay) broadcast(buildUpdate BuildUpdate) {
+	line, err := json.Marshal(newJSONBuildCollection(buildUpdate))
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.latest = line
+	for client := range r.clients {
+		select {
+		case <-client:
+		default:
+		}
+		client <- line
+	}
+}
+
+// serveClient writes lines to conn until writing fails.
+func (r *Relay) serveClient(conn net.Conn) {
+	defer conn.Close()
+
+	client := make(chan []byte, 1)
+	r.mutex.Lock()
+	if r.latest != nil {
+		client <- r.latest
+	}
+	r.clients[client] = true
+	r.mutex.Unlock()
+
+	defer func() {
+		r.mutex.Lock()
+		delete(r.clients, client)
+		r.mutex.Unlock()
+	}()
+
+	for line := range client {
+		conn.SetWriteDeadline(time.Now().Add(relayWriteTimeout))
+		if _, err := conn.Write(line); err != nil {
+			return
+		}
+	}
+}
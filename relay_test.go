@@ -0,0 +1,89 @@
+package monitrondashboard
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+// startTestRelay starts a Relay on a local port, returning it along with
+// the channel feeding it and its address.
+func startTestRelay(t *testing.T) (*Relay, chan BuildUpdate, string) {
+	fetcher := stubBuildFetcher{make(chan BuildUpdate)}
+	relay := NewRelay(fetcher)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error listening: %s", err)
+	}
+	go relay.Serve(listener)
+	go relay.Run()
+	t.Cleanup(func() { listener.Close() })
+	return relay, fetcher.buildChannel, listener.Addr().String()
+}
+
+func TestRelaySendsTheLatestUpdateToNewClients(t *testing.T) {
+	relay, _, address := startTestRelay(t)
+	relay.broadcast(BuildUpdate{builds: []build{
+		{name: "Failing Build", buildState: BuildStateFailed},
+	}})
+	relay.broadcast(BuildUpdate{builds: []build{
+		{name: "Build", buildState: BuildStatePassed},
+	}})
+
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("Unexpected error connecting to relay: %s", err)
+	}
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"builds","error":"","failing":[],"acknowledged":[],`+
+		`"healthy":[{"name":"Build","building":false,"user":"","url":"",`+
+		`"number_of_failures":0,"failing_since":0}]}`+"\n",
+		line)
+}
+
+func TestRelayClientsCanBeReadByABuildFetcher(t *testing.T) {
+	_, updates, address := startTestRelay(t)
+	updates <- BuildUpdate{builds: []build{
+		{name: "Failing Build", buildState: BuildStateFailed},
+	}}
+
+	fetcher := NewBuildFetcher(address)
+	buildUpdate := <-fetcher.BuildChannel()
+
+	assert.NoError(t, buildUpdate.err)
+	assert.Equal(t, []build{{name: "Failing Build", buildState: BuildStateFailed}},
+		buildUpdate.builds)
+}
+
+func TestRelayClientsAreSentTheKindOfError(t *testing.T) {
+	_, updates, address := startTestRelay(t)
+	updates <- BuildUpdate{
+		builds: []build{},
+		err:    buildError{BuildErrorNetwork, "Network Error"},
+	}
+
+	fetcher := NewBuildFetcher(address)
+	buildUpdate := <-fetcher.BuildChannel()
+
+	assert.Equal(t, buildError{BuildErrorNetwork, "Network Error"}, buildUpdate.err,
+		"The relay losing its connection should not look like a server error")
+}
+
+func TestRelayClientsAreSentTheBuildsOfWorkingServersWhenAnotherFails(t *testing.T) {
+	_, updates, address := startTestRelay(t)
+	updates <- BuildUpdate{
+		builds: []build{{name: "payments-api", buildState: BuildStateFailed}},
+		err:    buildError{BuildErrorNetwork, "release: Network Error"},
+	}
+
+	fetcher := NewBuildFetcher(address)
+	buildUpdate := <-fetcher.BuildChannel()
+
+	assert.Equal(t, buildError{BuildErrorNetwork, "release: Network Error"}, buildUpdate.err)
+	assert.Equal(t, []build{{name: "payments-api", buildState: BuildStateFailed}},
+		buildUpdate.builds)
+}
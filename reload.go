@@ -0,0 +1,92 @@
+package monitrondashboard
+
+// Reloading the configuration for the monitron dashboard.
+// Here you'll find code for watching the config file for changes so
+// that a running dashboard can pick up new settings without restarting.
+
+import (
+	"os"
+	"time"
+)
+
+// configPollInterval is how often the config file is checked for changes.
+const configPollInterval = 2 * time.Second
+
+// ConfigUpdate is sent when the config file is reloaded, with the new
+// settings or the error that stopped them being loaded.
+type ConfigUpdate struct {
+	Settings Settings
+	Err      error
+}
+
+// ConfigWatcher reloads a config file whenever it changes, or when it is
+// asked to, e.g. on SIGHUP.
+type ConfigWatcher struct {
+	path          string
+	profile       string
+	updateChannel chan ConfigUpdate
+	reloads       chan bool
+	modTime       time.Time
+	size          int64
+}
+
+// NewConfigWatcher creates a ConfigWatcher for the config file at path,
+// loading the named profile from it.
+func NewConfigWatcher(path string, profile string) *ConfigWatcher {
+	watcher := newConfigWatcher(path, profile)
+	go watcher.watch(time.NewTicker(configPollInterval).C)
+	return watcher
+}
+
+// newConfigWatcher creates a ConfigWatcher without starting it.
+func newConfigWatcher(path string, profile string) *ConfigWatcher {
+	watcher := &ConfigWatcher{
+		path:          path,
+		profile:       profile,
+		updateChannel: make(chan ConfigUpdate),
+		reloads:       make(chan bool),
+	}
+	watcher.changed()
+	return watcher
+}
+
+// UpdateChannel returns the channel the reloaded settings are sent on.
+func (w *ConfigWatcher) UpdateChannel() chan ConfigUpdate {
+	return w.updateChannel
+}
+
+// Reload reloads the config file whether or not it has changed.
+func (w *ConfigWatcher) Reload() {
+	w.reloads <- true
+}
+
+// watch checks the config file for changes whenever ticks sends,
+// reloading it when it has changed or Reload is called.
+func (w *ConfigWatcher) watch(ticks <-chan time.Time) {
+	for {
+		select {
+		case <-ticks:
+			if !w.changed() {
+				continue
+			}
+		case <-w.reloads:
+			w.changed()
+		}
+		settings, err := LoadConfig(w.path, w.profile)
+		w.updateChannel <- ConfigUpdate{Settings: settings, Err: err}
+	}
+}
+
+// changed returns true if the config file has been modified since it was
+// last checked. A missing file isn't a change, as editors often replace
+// the file when saving it.
+func (w *ConfigWatcher) changed() bool {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false
+	}
+	changed := !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+	return changed
+}
@@ -0,0 +1,85 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestConfig writes config to a temporary config file, returning
+// its path and the directory to remove once the test is done.
+func writeTestConfig(t *testing.T, config string) (string, string) {
+	dir, err := ioutil.TempDir("", "monidash")
+	if err != nil {
+		t.Fatalf("Unexpected error creating directory: %s", err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatalf("Unexpected error writing config: %s", err)
+	}
+	return path, dir
+}
+
+func TestConfigWatcherReloadsTheConfigWhenItChanges(t *testing.T) {
+	path, dir := writeTestConfig(t, "sort: name\n")
+	defer os.RemoveAll(dir)
+	watcher := newConfigWatcher(path, "")
+	ticks := make(chan time.Time)
+	go watcher.watch(ticks)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("sort: state\n"), 0644))
+	// Make sure the modification time changes on coarse file systems.
+	later := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(path, later, later))
+	ticks <- time.Now()
+
+	update := <-watcher.UpdateChannel()
+	assert.Nil(t, update.Err)
+	assert.Equal(t, SortByState, update.Settings.Sort)
+}
+
+func TestConfigWatcherIgnoresTicksWhenTheConfigIsUnchanged(t *testing.T) {
+	path, dir := writeTestConfig(t, "sort: name\n")
+	defer os.RemoveAll(dir)
+	watcher := newConfigWatcher(path, "")
+	ticks := make(chan time.Time)
+	go watcher.watch(ticks)
+
+	ticks <- time.Now()
+	ticks <- time.Now()
+
+	select {
+	case update := <-watcher.UpdateChannel():
+		t.Fatalf("Unexpected update %+v", update)
+	default:
+	}
+}
+
+func TestConfigWatcherReloadsWhenAskedTo(t *testing.T) {
+	path, dir := writeTestConfig(t, "sort: name\n")
+	defer os.RemoveAll(dir)
+	watcher := newConfigWatcher(path, "")
+	go watcher.watch(nil)
+
+	go watcher.Reload()
+
+	update := <-watcher.UpdateChannel()
+	assert.Nil(t, update.Err)
+	assert.Equal(t, SortByName, update.Settings.Sort)
+}
+
+func TestConfigWatcherSendsErrorsFromTheReloadedConfig(t *testing.T) {
+	path, dir := writeTestConfig(t, "sort: colour\n")
+	defer os.RemoveAll(dir)
+	watcher := newConfigWatcher(path, "")
+	go watcher.watch(nil)
+
+	go watcher.Reload()
+
+	update := <-watcher.UpdateChannel()
+	assert.EqualError(t, update.Err, path+
+		`: line 1: unknown sort order "colour", expected one of [server name state failing-since]`)
+}
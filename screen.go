@@ -0,0 +1,105 @@
+package monitrondashboard
+
+// Screens for the monitron dashboard.
+// Here you'll find the Screen interface the dashboard draws on and reads
+// input from, which keeps it independent of the terminal library, and
+// the events screens send.
+
+import (
+	"fmt"
+)
+
+// Screen is a terminal the dashboard can be drawn on and receive input
+// events from.
+type Screen interface {
+	CellDrawer
+	EventSource
+	// Init takes over the terminal, drawing in colours.
+	Init(colours ColourMode) error
+	// Close gives the terminal back.
+	Close()
+	// SetColourMode changes the colours the screen draws in.
+	SetColourMode(colours ColourMode)
+	// Size returns the width and height of the screen in cells.
+	Size() (int, int)
+	// Clear blanks every cell of the screen with fg and bg.
+	Clear(fg, bg Colour)
+}
+
+// EventSource is where the dashboard's input events come from.
+type EventSource interface {
+	// PollEvent waits for the next event.
+	PollEvent() Event
+}
+
+// EventType is the kind of an Event.
+type EventType int
+
+const (
+	// EventNone is an event the dashboard doesn't use, such as a mouse
+	// event.
+	EventNone EventType = iota
+	// EventKey is a key press.
+	EventKey
+	// EventResize is sent when the screen changes size.
+	EventResize
+	// EventError is sent when the screen can't read input.
+	EventError
+)
+
+// KeyCode is a key without a character, or KeyRune for the keys with one.
+type KeyCode int
+
+const (
+	// KeyRune is a key with a character, given by the event's Ch.
+	KeyRune KeyCode = iota
+	KeyEsc
+	KeySpace
+	// KeyOther is any other key without a character.
+	KeyOther
+)
+
+// Event is an input event from a Screen.
+type Event struct {
+	Type EventType
+	// Key and Ch are the key pressed for EventKey events.
+	Key KeyCode
+	Ch  rune
+	// Err is the error for EventError events.
+	Err error
+	// Width and Height are the new size of the screen for EventResize
+	// events.
+	Width  int
+	Height int
+}
+
+// Backend is the terminal library a Screen uses.
+type Backend string
+
+const (
+	BackendTermbox Backend = "termbox"
+	BackendTcell   Backend = "tcell"
+)
+
+// backends are the known Backends.
+var backends = []string{string(BackendTermbox), string(BackendTcell)}
+
+// ParseBackend returns the Backend named backend, or an error if it
+// isn't one of the backends.
+func ParseBackend(backend string) (Backend, error) {
+	for _, known := range backends {
+		if backend == known {
+			return Backend(backend), nil
+		}
+	}
+	return "", fmt.Errorf("unknown backend %q, expected one of %v", backend, backends)
+}
+
+// NewScreen creates a Screen using backend, which isn't initialised
+// until the dashboard runs.
+func NewScreen(backend Backend) Screen {
+	if backend == BackendTcell {
+		return NewTcellScreen()
+	}
+	return NewTermboxScreen()
+}
@@ -0,0 +1,64 @@
+package monitrondashboard
+
+// Scripted events for the monitron dashboard.
+// Here you'll find an EventSource that plays back a script of events, so
+// the dashboard's run loop can be driven without a terminal.
+
+// ScriptStep is a step of a script played by ScriptedEvents, returning
+// the event to send or an EventNone event to go straight on to the next
+// step.
+type ScriptStep func() Event
+
+// SendEvent is a step sending event.
+func SendEvent(event Event) ScriptStep {
+	return func() Event {
+		return event
+	}
+}
+
+// SendKey is a step pressing the key with the character ch.
+func SendKey(ch rune) ScriptStep {
+	return SendEvent(Event{Type: EventKey, Key: KeyRune, Ch: ch})
+}
+
+// SendResize is a step sending a resize event to width by height. The
+// dashboard resizes a VirtualScreen to match when it handles the event,
+// so the screen isn't changed while it is being drawn.
+func SendResize(width, height int) ScriptStep {
+	return SendEvent(Event{Type: EventResize, Width: width, Height: height})
+}
+
+// Do is a step calling action, such as sending a BuildUpdate, before
+// going on to the next step.
+func Do(action func()) ScriptStep {
+	return func() Event {
+		action()
+		return Event{Type: EventNone}
+	}
+}
+
+// ScriptedEvents is an EventSource playing back a script of steps. The
+// steps are run as the dashboard polls for events, which it does while
+// handling the event before, so Do steps shouldn't touch the screen.
+type ScriptedEvents struct {
+	steps []ScriptStep
+}
+
+// NewScriptedEvents creates ScriptedEvents playing steps in order.
+func NewScriptedEvents(steps ...ScriptStep) *ScriptedEvents {
+	return &ScriptedEvents{steps: steps}
+}
+
+// PollEvent runs steps until one returns an event to send, once the
+// script has finished it waits forever, as a terminal with no more input
+// would.
+func (s *ScriptedEvents) PollEvent() Event {
+	for len(s.steps) > 0 {
+		step := s.steps[0]
+		s.steps = s.steps[1:]
+		if event := step(); event.Type != EventNone {
+			return event
+		}
+	}
+	select {}
+}
@@ -0,0 +1,21 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestScriptedEventsPlaysItsStepsInOrder(t *testing.T) {
+	var done []string
+	events := NewScriptedEvents(
+		SendKey('n'),
+		Do(func() { done = append(done, "first") }),
+		Do(func() { done = append(done, "second") }),
+		SendResize(20, 8),
+	)
+
+	assert.Equal(t, Event{Type: EventKey, Key: KeyRune, Ch: 'n'}, events.PollEvent())
+	assert.Empty(t, done, "Steps after an event should wait for the next poll")
+	assert.Equal(t, Event{Type: EventResize, Width: 20, Height: 8}, events.PollEvent())
+	assert.Equal(t, []string{"first", "second"}, done)
+}
@@ -0,0 +1,132 @@
+package monitrondashboard
+
+// Server connections for the monitron dashboard.
+// Here you'll find code for connecting to the servers given in the
+// settings, and reconnecting when they are changed.
+
+import (
+	"io"
+)
+
+// ServerBuildFetcher is a BuildFetcher for the builds from a list of
+// servers, merging them when there is more than one. The servers can be
+// changed while it runs.
+type ServerBuildFetcher struct {
+	servers      []ServerConfig
+	recording    io.Writer
+	buildChannel chan BuildUpdate
+	changes      chan []ServerConfig
+	// connect creates a fetcher for a server, and can be replaced in
+	// tests.
+	connect func(server ServerConfig, recording io.Writer) closableBuildFetcher
+}
+
+// closableBuildFetcher is a BuildFetcher that can be told to stop,
+// closing its channel once it has.
+type closableBuildFetcher interface {
+	BuildFetcher
+	io.Closer
+}
+
+// NewServerBuildFetcher creates a ServerBuildFetcher for servers. If
+// there is only one server everything received from it is written to
+// recording, if it isn't nil.
+func NewServerBuildFetcher(servers []ServerConfig, recording io.Writer) *ServerBuildFetcher {
+	serverFetcher := newServerBuildFetcher(servers, recording,
+		func(server ServerConfig, recording io.Writer) closableBuildFetcher {
+			return newTCPBuildFetcher(server.Address, recording, reconnectDelay,
+				SystemClock)
+		})
+	go serverFetcher.forwardBuilds()
+	return serverFetcher
+}
+
+// newServerBuildFetcher creates a ServerBuildFetcher using connect to
+// create the fetcher for each server, without starting it.
+func newServerBuildFetcher(servers []ServerConfig, recording io.Writer,
+	connect func(ServerConfig, io.Writer) closableBuildFetcher) *ServerBuildFetcher {
+	return &ServerBuildFetcher{
+		servers:      servers,
+		recording:    recording,
+		buildChannel: make(chan BuildUpdate),
+		changes:      make(chan []ServerConfig),
+		connect:      connect,
+	}
+}
+
+func (s *ServerBuildFetcher) BuildChannel() chan BuildUpdate {
+	return s.buildChannel
+}
+
+// SetServers changes the servers builds are fetched from, reconnecting
+// only if they are different from the current servers.
+func (s *ServerBuildFetcher) SetServers(servers []ServerConfig) {
+	s.changes <- servers
+}
+
+// forwardBuilds passes on the builds from the current servers, closing
+// the connections to the old servers when they are changed.
+func (s *ServerBuildFetcher) forwardBuilds() {
+	fetcher, connections := s.connectAll()
+	buildChannel := fetcher.BuildChannel()
+	for {
+		select {
+		case buildUpdate, ok := <-buildChannel:
+			if !ok {
+				// There are no servers left to fetch from, wait for them
+				// to be changed.
+				buildChannel = nil
+				continue
+			}
+			s.buildChannel <- buildUpdate
+		case servers := <-s.changes:
+			if sameServers(s.servers, servers) {
+				continue
+			}
+			for _, connection := range connections {
+				connection.Close()
+			}
+			// Nothing reads the old fetcher any more, so drain it until
+			// it has finished.
+			go func(old BuildFetcher) {
+				for range old.BuildChannel() {
+				}
+			}(fetcher)
+			s.servers = servers
+			fetcher, connections = s.connectAll()
+			buildChannel = fetcher.BuildChannel()
+		}
+	}
+}
+
+// connectAll connects to each of the servers, returning a fetcher for
+// all of their builds and the connections to close when they change.
+func (s *ServerBuildFetcher) connectAll() (BuildFetcher, []io.Closer) {
+	if len(s.servers) == 1 {
+		connection := s.connect(s.servers[0], s.recording)
+		return connection, []io.Closer{connection}
+	}
+
+	sources := make([]BuildSource, len(s.servers))
+	connections := make([]io.Closer, len(s.servers))
+	for i, server := range s.servers {
+		connection := s.connect(server, nil)
+		sources[i] = BuildSource{Name: server.Name, Fetcher: connection}
+		connections[i] = connection
+	}
+	return NewMergedBuildFetcher(sources), connections
+}
+
+// sameServers returns true if a and b are the same servers in the same
+// order.
+func sameServers(a, b []ServerConfig) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
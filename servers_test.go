@@ -0,0 +1,80 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+// closableStubBuildFetcher is a stubBuildFetcher that records whether
+// it has been closed.
+type closableStubBuildFetcher struct {
+	stubBuildFetcher
+	closed bool
+}
+
+func (s *closableStubBuildFetcher) Close() error {
+	s.closed = true
+	close(s.buildChannel)
+	return nil
+}
+
+// newStubServerBuildFetcher creates a started ServerBuildFetcher that
+// connects to stub fetchers, which are sent on connections as they're
+// created.
+func newStubServerBuildFetcher(servers []ServerConfig) (*ServerBuildFetcher,
+	chan *closableStubBuildFetcher) {
+	connections := make(chan *closableStubBuildFetcher, 10)
+	serverFetcher := newServerBuildFetcher(servers, nil,
+		func(server ServerConfig, recording io.Writer) closableBuildFetcher {
+			connection := &closableStubBuildFetcher{
+				stubBuildFetcher: stubBuildFetcher{make(chan BuildUpdate)},
+			}
+			connections <- connection
+			return connection
+		})
+	go serverFetcher.forwardBuilds()
+	return serverFetcher, connections
+}
+
+func TestServerBuildFetcherForwardsBuildsFromASingleServer(t *testing.T) {
+	serverFetcher, connections := newStubServerBuildFetcher(
+		[]ServerConfig{{Name: "ci", Address: "ci:9988"}})
+	connection := <-connections
+
+	connection.buildChannel <- BuildUpdate{builds: []build{{name: "A"}}}
+
+	assert.Equal(t, []build{{name: "A"}}, (<-serverFetcher.BuildChannel()).builds)
+}
+
+func TestServerBuildFetcherMergesBuildsFromSeveralServers(t *testing.T) {
+	serverFetcher, connections := newStubServerBuildFetcher([]ServerConfig{
+		{Name: "ci", Address: "ci:9988"},
+		{Name: "release", Address: "release:9988"},
+	})
+	<-connections
+	release := <-connections
+
+	release.buildChannel <- BuildUpdate{builds: []build{{name: "A"}}}
+
+	assert.Equal(t, []build{{name: "A", source: "release"}},
+		(<-serverFetcher.BuildChannel()).builds)
+}
+
+func TestServerBuildFetcherOnlyReconnectsWhenTheServersChange(t *testing.T) {
+	servers := []ServerConfig{{Name: "ci", Address: "ci:9988"}}
+	serverFetcher, connections := newStubServerBuildFetcher(servers)
+	first := <-connections
+
+	serverFetcher.SetServers([]ServerConfig{{Name: "ci", Address: "ci:9988"}})
+	first.buildChannel <- BuildUpdate{builds: []build{{name: "A"}}}
+	assert.Equal(t, []build{{name: "A"}}, (<-serverFetcher.BuildChannel()).builds)
+	assert.False(t, first.closed)
+	assert.Len(t, connections, 0)
+
+	serverFetcher.SetServers([]ServerConfig{{Name: "new", Address: "new:9988"}})
+	second := <-connections
+	assert.True(t, first.closed)
+	second.buildChannel <- BuildUpdate{builds: []build{{name: "B"}}}
+	assert.Equal(t, []build{{name: "B"}}, (<-serverFetcher.BuildChannel()).builds)
+}
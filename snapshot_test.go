@@ -0,0 +1,173 @@
+package monitrondashboard
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update regenerates the golden files from the frames drawn, run with
+// go test -run TestSnapshots -update.
+var update = flag.Bool("update", false, "update the snapshot golden files")
+
+// snapshotStep is a change made to the dashboard before a frame is
+// compared, either resizing the screen or sending a BuildUpdate.
+type snapshotStep struct {
+	width, height int
+	update        *BuildUpdate
+}
+
+// snapshotScenario is a sequence of steps drawn on a screen of width by
+// height, with frames compared to testdata/snapshots/name.golden.
+type snapshotScenario struct {
+	name          string
+	settings      Settings
+	width, height int
+	steps         []snapshotStep
+}
+
+var snapshotBuilds = []build{
+	{name: "payments-api", buildState: BuildStateFailed},
+	{name: "payments-web", buildState: BuildStateAcknowledged, acknowledger: "Dave"},
+	{name: "search", buildState: BuildStatePassed, building: true},
+	{name: "reporting-nightly", buildState: BuildStateUnknown},
+	{name: "a-build-with-a-name-too-long-for-its-box", buildState: BuildStatePassed},
+}
+
+// snapshotSettings are the DefaultSettings drawing in 256 colours, rather
+// than detecting them from the terminal running the tests.
+func snapshotSettings() Settings {
+	settings := DefaultSettings()
+	settings.Colours = ColourMode256
+	return settings
+}
+
+var snapshotScenarios = []snapshotScenario{
+	{
+		name:     "builds",
+		settings: snapshotSettings(),
+		width:    80, height: 24,
+		steps: []snapshotStep{
+			{update: &BuildUpdate{builds: snapshotBuilds}},
+			{width: 100, height: 16},
+			{update: &BuildUpdate{builds: snapshotBuilds[1:3]}},
+		},
+	},
+	{
+		name:     "escalated",
+		settings: snapshotSettings(),
+		width:    64, height: 10,
+		steps: []snapshotStep{
+			{update: &BuildUpdate{builds: []build{
+				snapshotBuilds[2],
+				{name: "payments-api", buildState: BuildStateFailed, escalated: true},
+			}}},
+		},
+	},
+	{
+		name:     "errors",
+		settings: snapshotSettings(),
+		width:    80, height: 24,
+		steps: []snapshotStep{
+			{update: &BuildUpdate{err: buildError{BuildErrorNetwork, "connection refused"}}},
+			{update: &BuildUpdate{builds: snapshotBuilds}},
+			{width: 40, height: 8},
+		},
+	},
+	{
+		name:     "monochrome",
+		settings: Settings{Colours: ColourModeMonochrome, Layout: DefaultLayout},
+		width:    80, height: 16,
+		steps: []snapshotStep{
+			{update: &BuildUpdate{builds: snapshotBuilds[:4]}},
+		},
+	},
+}
+
+func TestSnapshots(t *testing.T) {
+	for _, scenario := range snapshotScenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			assertGolden(t, filepath.Join("testdata", "snapshots", scenario.name+".golden"),
+				drawSnapshotFrames(scenario))
+		})
+	}
+}
+
+// drawSnapshotFrames runs the steps of scenario, returning every frame
+// drawn with a heading describing the step.
+func drawSnapshotFrames(scenario snapshotScenario) string {
+	screen := NewVirtualScreen(scenario.width, scenario.height)
+	dashboard := NewDashboard(nil, screen)
+	dashboard.ApplySettings(scenario.settings)
+
+	var frames bytes.Buffer
+	dashboard.resize()
+	writeFrame(&frames, "start", screen)
+	for i, step := range scenario.steps {
+		var description string
+		if step.update != nil {
+			dashboard.updateBuilds(*step.update)
+			description = fmt.Sprintf("update with %d builds", len(step.update.builds))
+			if step.update.err != nil {
+				description = fmt.Sprintf("update with error %q", step.update.err)
+			}
+		} else {
+			screen.Resize(step.width, step.height)
+			dashboard.resize()
+			description = "resize"
+		}
+		writeFrame(&frames, fmt.Sprintf("step %d: %s", i+1, description), screen)
+	}
+	return frames.String()
+}
+
+// writeFrame writes the characters on screen followed by a map of the
+// style of each cell, with a key for each style.
+func writeFrame(frames *bytes.Buffer, heading string, screen *VirtualScreen) {
+	width, height := screen.Size()
+	fmt.Fprintf(frames, "=== %s, %dx%d\n", heading, width, height)
+	frames.WriteString(screen.Text())
+
+	frames.WriteString("--- styles\n")
+	keys := map[[2]Colour]rune{}
+	var styles [][2]Colour
+	for _, row := range screen.Grid() {
+		for _, cell := range row {
+			style := [2]Colour{cell.Fg, cell.Bg}
+			key, ok := keys[style]
+			if !ok {
+				key = rune('a' + len(styles))
+				keys[style] = key
+				styles = append(styles, style)
+			}
+			frames.WriteRune(key)
+		}
+		frames.WriteRune('\n')
+	}
+	for _, style := range styles {
+		fmt.Fprintf(frames, "%c: %s on %s\n", keys[style], style[0], style[1])
+	}
+}
+
+// assertGolden compares actual to the golden file at path, or writes it
+// to the file when -update is given.
+func assertGolden(t *testing.T, path string, actual string) {
+	if *update {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(actual), 0644))
+		return
+	}
+	expected, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("%s is missing, run go test -run TestSnapshots -update to create it", path)
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, string(expected), actual,
+		"%s doesn't match, run go test -run TestSnapshots -update if the change is expected", path)
+}
@@ -0,0 +1,149 @@
+package monitrondashboard
+
+// Status bar output for the monitron dashboard.
+// Here you'll find code for printing a one line summary of build health
+// suitable for tmux, i3blocks or polybar.
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+// statusCount is a single entry in the status line.
+type statusCount struct {
+	glyph      string
+	tmuxColour string
+	count      int
+}
+
+// StatusPrinter prints a one line summary of the number of builds in each
+// state, either for every BuildUpdate or at a fixed interval.
+type StatusPrinter struct {
+	fetcher  BuildFetcher
+	writer   io.Writer
+	tmux     bool
+	interval time.Duration
+	theme    Theme
+}
+
+// NewStatusPrinter creates a StatusPrinter that writes summaries of the
+// updates from fetcher to writer. If tmux is true the counts are wrapped
+// in the tmux colour codes of their state in theme, if interval is non
+// zero the latest summary is printed every interval rather than on every
+// update.
+func NewStatusPrinter(fetcher BuildFetcher, writer io.Writer, tmux bool,
+	interval time.Duration, theme Theme) StatusPrinter {
+	return StatusPrinter{
+		fetcher:  fetcher,
+		writer:   writer,
+		tmux:     tmux,
+		interval: interval,
+		theme:    theme,
+	}
+}
+
+// Run prints summaries until writing fails.
+func (p StatusPrinter) Run() error {
+	var tick <-chan time.Time
+	if p.interval > 0 {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var line string
+	for {
+		select {
+		case buildUpdate, ok := <-p.fetcher.BuildChannel():
+			if !ok {
+				return nil
+			}
+			line = p.statusLine(buildUpdate)
+			if tick != nil {
+				continue
+			}
+		case <-tick:
+			if line == "" {
+				continue
+			}
+		}
+		if _, err := fmt.Fprintln(p.writer, line); err != nil {
+			return err
+		}
+	}
+}
+
+// statusLine returns the summary line for buildUpdate, e.g. "✗3 ⚠1 ✓120 ⟳4".
+// An error is shown after the counts of any builds the update still has.
+func (p StatusPrinter) statusLine(buildUpdate BuildUpdate) string {
+	var errorText string
+	if buildUpdate.err != nil {
+		errorText = p.colour(fmt.Sprintf("Error: %s", buildUpdate.err), "red")
+		if len(buildUpdate.builds) == 0 {
+			return errorText
+		}
+	}
+
+	failed := statusCount{"✗", tmuxColour(p.theme.Failed.Bg), 0}
+	acknowledged := statusCount{"⚠", tmuxColour(p.theme.Acknowledged.Bg), 0}
+	passed := statusCount{"✓", tmuxColour(p.theme.Passed.Bg), 0}
+	unknown := statusCount{"?", tmuxColour(p.theme.Unknown.Bg), 0}
+	building := statusCount{"⟳", "", 0}
+	for _, build := range buildUpdate.builds {
+		switch build.buildState {
+		case BuildStateFailed:
+			failed.count++
+		case BuildStateAcknowledged:
+			acknowledged.count++
+		case BuildStatePassed:
+			passed.count++
+		default:
+			unknown.count++
+		}
+		if build.building {
+			building.count++
+		}
+	}
+
+	counts := []statusCount{failed, acknowledged, passed}
+	if unknown.count > 0 {
+		counts = append(counts, unknown)
+	}
+	counts = append(counts, building)
+
+	var buffer bytes.Buffer
+	for i, count := range counts {
+		if i > 0 {
+			buffer.WriteRune(' ')
+		}
+		buffer.WriteString(p.colour(
+			fmt.Sprintf("%s%d", count.glyph, count.count), count.tmuxColour))
+	}
+	if errorText != "" {
+		buffer.WriteString(" " + errorText)
+	}
+	return buffer.String()
+}
+
+// tmuxColour returns the tmux name for a colour, a hex colour for RGB
+// colours and otherwise its name in the 256 colour palette. The default
+// colour has no name, as the text is left uncoloured.
+func tmuxColour(colour Colour) string {
+	if colour.isDefault() {
+		return ""
+	}
+	if colour&rgbFlag != 0 {
+		return colourToHex(colour)
+	}
+	return fmt.Sprintf("colour%d", int(colour&paletteMask)-1)
+}
+
+// colour wraps text in a tmux colour code if tmux output is enabled.
+func (p StatusPrinter) colour(text string, tmuxColour string) string {
+	if !p.tmux || tmuxColour == "" {
+		return text
+	}
+	return fmt.Sprintf("#[fg=%s]%s#[default]", tmuxColour, text)
+}
@@ -0,0 +1,72 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var statusTestBuilds = []build{
+	{name: "a", buildState: BuildStateFailed},
+	{name: "b", buildState: BuildStateFailed, building: true},
+	{name: "c", buildState: BuildStateAcknowledged, acknowledger: "Dave"},
+	{name: "d", buildState: BuildStatePassed, building: true},
+	{name: "e", buildState: BuildStatePassed},
+}
+
+func TestStatusLineCountsBuildsInEachState(t *testing.T) {
+	printer := NewStatusPrinter(nil, nil, false, 0, DefaultTheme)
+
+	line := printer.statusLine(BuildUpdate{builds: statusTestBuilds})
+
+	assert.Equal(t, "✗2 ⚠1 ✓2 ⟳2", line)
+}
+
+func TestStatusLineOnlyShowsUnknownBuildsWhenThereAreSome(t *testing.T) {
+	printer := NewStatusPrinter(nil, nil, false, 0, DefaultTheme)
+
+	line := printer.statusLine(BuildUpdate{builds: []build{
+		{name: "a", buildState: BuildStateUnknown},
+	}})
+
+	assert.Equal(t, "✗0 ⚠0 ✓0 ?1 ⟳0", line)
+}
+
+func TestStatusLineWithTmuxColours(t *testing.T) {
+	printer := NewStatusPrinter(nil, nil, true, 0, DefaultTheme)
+
+	line := printer.statusLine(BuildUpdate{builds: statusTestBuilds})
+
+	assert.Equal(t, "#[fg=colour1]✗2#[default] #[fg=colour166]⚠1#[default] "+
+		"#[fg=colour2]✓2#[default] ⟳2", line)
+}
+
+func TestStatusLineWithTmuxColoursFromTheTheme(t *testing.T) {
+	printer := NewStatusPrinter(nil, nil, true, 0, BuiltinThemes["high-contrast"])
+
+	line := printer.statusLine(BuildUpdate{builds: statusTestBuilds})
+
+	assert.Equal(t, "#[fg=colour196]✗2#[default] #[fg=colour226]⚠1#[default] "+
+		"#[fg=colour46]✓2#[default] ⟳2", line)
+}
+
+func TestStatusLineShowsErrors(t *testing.T) {
+	printer := NewStatusPrinter(nil, nil, false, 0, DefaultTheme)
+
+	line := printer.statusLine(BuildUpdate{
+		builds: []build{},
+		err:    buildError{BuildErrorNetwork, "Network Error"},
+	})
+
+	assert.Equal(t, "Error: Network Error", line)
+}
+
+func TestStatusLineCountsTheBuildsOfWorkingServersWhenAnotherFails(t *testing.T) {
+	printer := NewStatusPrinter(nil, nil, false, 0, DefaultTheme)
+
+	line := printer.statusLine(BuildUpdate{
+		builds: []build{{name: "a", buildState: BuildStateFailed, source: "ci"}},
+		err:    buildError{BuildErrorNetwork, "release: Network Error"},
+	})
+
+	assert.Equal(t, "✗1 ⚠0 ✓0 ⟳0 Error: release: Network Error", line)
+}
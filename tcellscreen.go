@@ -0,0 +1,109 @@
+package monitrondashboard
+
+// The tcell screen for the monitron dashboard.
+// Here you'll find the Screen that draws on the terminal using tcell,
+// which reads the terminal's capabilities from terminfo.
+
+import (
+	"errors"
+	"github.com/gdamore/tcell/v2"
+)
+
+// TcellScreen implements Screen using tcell to draw on the terminal.
+type TcellScreen struct {
+	screen     tcell.Screen
+	trueColour bool
+	// newScreen creates the tcell screen when it is initialised, and
+	// can be replaced in tests.
+	newScreen func() (tcell.Screen, error)
+}
+
+// NewTcellScreen creates a TcellScreen.
+func NewTcellScreen() *TcellScreen {
+	return &TcellScreen{newScreen: tcell.NewScreen}
+}
+
+func (t *TcellScreen) Init(colours ColourMode) error {
+	screen, err := t.newScreen()
+	if err != nil {
+		return err
+	}
+	if err := screen.Init(); err != nil {
+		return err
+	}
+	t.screen = screen
+	t.SetColourMode(colours)
+	return nil
+}
+
+func (t *TcellScreen) Close() {
+	t.screen.Fini()
+}
+
+func (t *TcellScreen) SetColourMode(colours ColourMode) {
+	t.trueColour = colours == ColourModeTrueColour
+}
+
+func (t *TcellScreen) Size() (int, int) {
+	return t.screen.Size()
+}
+
+func (t *TcellScreen) Clear(fg, bg Colour) {
+	t.screen.SetStyle(t.style(fg, bg))
+	t.screen.Clear()
+}
+
+func (t *TcellScreen) SetCell(x, y int, ch rune, fg, bg Colour) {
+	t.screen.SetContent(x, y, ch, nil, t.style(fg, bg))
+}
+
+func (t *TcellScreen) Flush() {
+	t.screen.Show()
+}
+
+func (t *TcellScreen) PollEvent() Event {
+	switch ev := t.screen.PollEvent().(type) {
+	case *tcell.EventKey:
+		switch {
+		case ev.Key() == tcell.KeyEscape:
+			return Event{Type: EventKey, Key: KeyEsc}
+		case ev.Key() == tcell.KeyRune && ev.Rune() == ' ':
+			return Event{Type: EventKey, Key: KeySpace}
+		case ev.Key() == tcell.KeyRune:
+			return Event{Type: EventKey, Key: KeyRune, Ch: ev.Rune()}
+		}
+		return Event{Type: EventKey, Key: KeyOther}
+	case *tcell.EventResize:
+		width, height := ev.Size()
+		return Event{Type: EventResize, Width: width, Height: height}
+	case *tcell.EventError:
+		return Event{Type: EventError, Err: ev}
+	case nil:
+		// tcell returns nil once the screen has been closed.
+		return Event{Type: EventError, Err: errors.New("screen closed")}
+	}
+	return Event{Type: EventNone}
+}
+
+// style returns the tcell style for drawing with fg and bg.
+func (t *TcellScreen) style(fg, bg Colour) tcell.Style {
+	return tcell.StyleDefault.
+		Foreground(fg.tcellColour(t.trueColour)).
+		Background(bg.tcellColour(t.trueColour)).
+		Bold(fg&AttrBold != 0).
+		Underline(fg&AttrUnderline != 0).
+		Reverse((fg|bg)&AttrReverse != 0)
+}
+
+// tcellColour returns the tcell colour c is drawn with, as an RGB colour
+// if trueColour is set, otherwise from the 256 colour palette.
+func (c Colour) tcellColour(trueColour bool) tcell.Color {
+	switch {
+	case c.isDefault():
+		return tcell.ColorDefault
+	case trueColour:
+		r, g, b := c.rgb()
+		return tcell.NewRGBColor(int32(r), int32(g), int32(b))
+	}
+	return tcell.PaletteColor(int(c.quantise(256)&paletteMask) - 1)
+}
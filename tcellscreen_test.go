@@ -0,0 +1,79 @@
+package monitrondashboard
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// newSimulatedTcellScreen creates an initialised TcellScreen drawing on a
+// tcell simulation screen.
+func newSimulatedTcellScreen(t *testing.T, colours ColourMode) (*TcellScreen, tcell.SimulationScreen) {
+	simulation := tcell.NewSimulationScreen("UTF-8")
+	screen := &TcellScreen{newScreen: func() (tcell.Screen, error) {
+		return simulation, nil
+	}}
+	assert.Nil(t, screen.Init(colours))
+	simulation.SetSize(40, 10)
+	return screen, simulation
+}
+
+func TestTcellScreenDrawsCellsWithTheirColours(t *testing.T) {
+	screen, simulation := newSimulatedTcellScreen(t, ColourMode256)
+	defer screen.Close()
+
+	screen.SetCell(2, 1, '✗', ColourWhite|AttrBold, xtermColour(166))
+	screen.SetCell(3, 1, '!', ColourDefault, ColourDefault|AttrReverse)
+	screen.Flush()
+
+	char, _, style, _ := simulation.GetContent(2, 1)
+	assert.Equal(t, '✗', char)
+	assert.Equal(t, tcell.StyleDefault.Foreground(tcell.PaletteColor(7)).
+		Background(tcell.PaletteColor(166)).Bold(true), style)
+	_, _, style, _ = simulation.GetContent(3, 1)
+	assert.Equal(t, tcell.StyleDefault.Reverse(true), style)
+}
+
+func TestTcellScreenDrawsRGBColoursOnlyWithTrueColour(t *testing.T) {
+	orange := RGBColour(0xd7, 0x5f, 0x00)
+
+	assert.Equal(t, tcell.NewRGBColor(0xd7, 0x5f, 0x00), orange.tcellColour(true))
+	assert.Equal(t, tcell.PaletteColor(166), orange.tcellColour(false))
+	assert.Equal(t, tcell.ColorDefault, ColourDefault.tcellColour(true))
+}
+
+func TestTcellScreenSizeIsTheTerminalSize(t *testing.T) {
+	screen, _ := newSimulatedTcellScreen(t, ColourMode256)
+	defer screen.Close()
+
+	width, height := screen.Size()
+	assert.Equal(t, 40, width)
+	assert.Equal(t, 10, height)
+}
+
+var tcellEventTests = []struct {
+	event tcell.Event
+	out   Event
+}{
+	{tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone), Event{Type: EventKey, Key: KeyRune, Ch: 'q'}},
+	{tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), Event{Type: EventKey, Key: KeySpace}},
+	{tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone), Event{Type: EventKey, Key: KeyEsc}},
+	{tcell.NewEventKey(tcell.KeyF1, 0, tcell.ModNone), Event{Type: EventKey, Key: KeyOther}},
+	{tcell.NewEventResize(80, 24), Event{Type: EventResize, Width: 80, Height: 24}},
+	{tcell.NewEventInterrupt(nil), Event{Type: EventNone}},
+}
+
+func TestTcellScreenTranslatesEvents(t *testing.T) {
+	screen, simulation := newSimulatedTcellScreen(t, ColourMode256)
+	defer screen.Close()
+
+	for _, test := range tcellEventTests {
+		assert.Nil(t, simulation.PostEvent(test.event))
+		event := screen.PollEvent()
+		if event.Type == EventResize && test.out.Type != EventResize {
+			// The simulation screen sends a resize when it starts.
+			event = screen.PollEvent()
+		}
+		assert.Equal(t, test.out, event, "%#v", test.event)
+	}
+}
@@ -0,0 +1,167 @@
+package monitrondashboard
+
+// Build box templates for the monitron dashboard.
+// Here you'll find the templates saying which of a build's details are
+// shown in its box and where, and the text each detail is shown as.
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// templateFields are the details of a build a template can show.
+var templateFields = []string{"name", "state", "acknowledger", "building", "failures",
+	"failing_since", "source", "url_host"}
+
+// alignmentNames are the names of the Alignments a field can be given,
+// in order.
+var alignmentNames = []string{"left", "centre", "right"}
+
+// TemplateField is a detail of a build shown in its box, placed within
+// the space left in its row by Align.
+type TemplateField struct {
+	Name  string
+	Align Alignment
+}
+
+// TemplateRow is a row of fields in a build box. Fields that are empty
+// for a build, such as the acknowledger of a passing build, are left
+// out.
+type TemplateRow []TemplateField
+
+// BoxTemplate is the rows of details shown in a build box beside the
+// swatch of its state.
+type BoxTemplate []TemplateRow
+
+// DefaultBoxTemplate shows the name of a build, with whether it is
+// building and who acknowledged it below.
+var DefaultBoxTemplate = BoxTemplate{
+	{{Name: "name"}},
+	{{Name: "building"}, {Name: "acknowledger"}},
+}
+
+// ParseTemplateRow parses a row of field names separated by spaces, each
+// optionally followed by :left, :centre or :right, such as
+// "failures failing_since:right".
+func ParseTemplateRow(row string) (TemplateRow, error) {
+	fields := TemplateRow{}
+	for _, field := range strings.Fields(row) {
+		parts := strings.SplitN(field, ":", 2)
+		templateField := TemplateField{Name: parts[0]}
+		if indexOf(templateFields, templateField.Name) < 0 {
+			return nil, fmt.Errorf("unknown template field %q, expected one of %v",
+				templateField.Name, templateFields)
+		}
+		if len(parts) == 2 {
+			align := indexOf(alignmentNames, parts[1])
+			if align < 0 {
+				return nil, fmt.Errorf("unknown alignment %q, expected one of %v",
+					parts[1], alignmentNames)
+			}
+			templateField.Align = Alignment(align)
+		}
+		fields = append(fields, templateField)
+	}
+	return fields, nil
+}
+
+// String returns the row as it is written in the config file.
+func (r TemplateRow) String() string {
+	fields := make([]string, len(r))
+	for i, field := range r {
+		fields[i] = field.Name
+		if field.Align != AlignLeft {
+			fields[i] += ":" + alignmentNames[field.Align]
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// UnmarshalYAML decodes a row in the same form as ParseTemplateRow.
+func (r *TemplateRow) UnmarshalYAML(value *yaml.Node) error {
+	var row string
+	if err := value.Decode(&row); err != nil {
+		return err
+	}
+	parsed, err := ParseTemplateRow(row)
+	if err != nil {
+		return configError(value, "%s", err)
+	}
+	*r = parsed
+	return nil
+}
+
+// shows returns true if any row of the template shows the field called
+// name.
+func (t BoxTemplate) shows(name string) bool {
+	for _, row := range t {
+		for _, field := range row {
+			if field.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// templateText returns the text shown for the field called name of
+// build, or an empty string if there is nothing to show, at now.
+func templateText(name string, build build, now time.Time) string {
+	switch name {
+	case "name":
+		return build.name
+	case "state":
+		return build.buildState.String()
+	case "acknowledger":
+		return build.acknowledger
+	case "building":
+		if build.building {
+			return buildingMessage
+		}
+	case "failures":
+		if build.failures == 1 {
+			return "1 failure"
+		} else if build.failures > 1 {
+			return fmt.Sprintf("%d failures", build.failures)
+		}
+	case "failing_since":
+		if !build.failingSince.IsZero() {
+			return "failing " + formatAge(now.Sub(build.failingSince))
+		}
+	case "source":
+		return build.source
+	case "url_host":
+		if parsed, err := url.Parse(build.url); err == nil {
+			return parsed.Host
+		}
+	}
+	return ""
+}
+
+// formatAge returns age in a few characters, such as 45s, 13m, 2h13m or
+// 3d4h.
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age/time.Second))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age/time.Minute))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(age/time.Hour), int(age%time.Hour/time.Minute))
+	}
+	day := 24 * time.Hour
+	return fmt.Sprintf("%dd%dh", int(age/day), int(age%day/time.Hour))
+}
+
+// indexOf returns the index of choice in choices, or -1 if it isn't one.
+func indexOf(choices []string, choice string) int {
+	for i, known := range choices {
+		if known == choice {
+			return i
+		}
+	}
+	return -1
+}
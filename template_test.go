@@ -0,0 +1,81 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseTemplateRow(t *testing.T) {
+	row, err := ParseTemplateRow("  name state:centre  failing_since:right ")
+
+	assert.Nil(t, err)
+	assert.Equal(t, TemplateRow{
+		{Name: "name"},
+		{Name: "state", Align: AlignCentre},
+		{Name: "failing_since", Align: AlignRight},
+	}, row)
+	assert.Equal(t, "name state:centre failing_since:right", row.String())
+}
+
+func TestParseTemplateRowRejectsUnknownFieldsAndAlignments(t *testing.T) {
+	_, err := ParseTemplateRow("name duration")
+	assert.EqualError(t, err, `unknown template field "duration", expected one of `+
+		`[name state acknowledger building failures failing_since source url_host]`)
+
+	_, err = ParseTemplateRow("name:top")
+	assert.EqualError(t, err, `unknown alignment "top", expected one of [left centre right]`)
+}
+
+func TestTemplateTextShowsEachField(t *testing.T) {
+	now := time.Unix(1425590828, 0)
+	testBuild := build{
+		name:         "Test Build",
+		buildState:   BuildStateFailed,
+		building:     true,
+		acknowledger: "Dave",
+		failingSince: now.Add(-(2*time.Hour + 13*time.Minute + 5*time.Second)),
+		failures:     3,
+		source:       "ci",
+		url:          "http://jenkins.example.com:8080/job/Test%20Build/",
+	}
+
+	for field, text := range map[string]string{
+		"name":          "Test Build",
+		"state":         "failed",
+		"acknowledger":  "Dave",
+		"building":      "Building",
+		"failures":      "3 failures",
+		"failing_since": "failing 2h13m",
+		"source":        "ci",
+		"url_host":      "jenkins.example.com:8080",
+	} {
+		assert.Equal(t, text, templateText(field, testBuild, now), field)
+	}
+}
+
+func TestTemplateTextIsEmptyForMissingDetails(t *testing.T) {
+	testBuild := build{name: "Test Build", buildState: BuildStatePassed}
+
+	for _, field := range []string{"acknowledger", "building", "failures",
+		"failing_since", "source", "url_host"} {
+		assert.Equal(t, "", templateText(field, testBuild, time.Now()), field)
+	}
+	assert.Equal(t, "1 failure", templateText("failures", build{failures: 1}, time.Now()))
+}
+
+var ageTests = []struct {
+	age time.Duration
+	out string
+}{
+	{45 * time.Second, "45s"},
+	{13*time.Minute + 20*time.Second, "13m"},
+	{2*time.Hour + 13*time.Minute, "2h13m"},
+	{76*time.Hour + 30*time.Minute, "3d4h"},
+}
+
+func TestFormatAge(t *testing.T) {
+	for _, test := range ageTests {
+		assert.Equal(t, test.out, formatAge(test.age), "%s", test.age)
+	}
+}
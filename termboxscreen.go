@@ -0,0 +1,119 @@
+package monitrondashboard
+
+// The termbox screen for the monitron dashboard.
+// Here you'll find the Screen that draws on the terminal using termbox.
+
+import (
+	"github.com/nsf/termbox-go"
+)
+
+// TermboxScreen implements Screen using termbox to draw on the terminal.
+type TermboxScreen struct {
+	trueColour bool
+}
+
+// NewTermboxScreen creates a TermboxScreen.
+func NewTermboxScreen() *TermboxScreen {
+	return &TermboxScreen{}
+}
+
+func (t *TermboxScreen) Init(colours ColourMode) error {
+	if err := termbox.Init(); err != nil {
+		return err
+	}
+	termbox.SetInputMode(termbox.InputEsc)
+	t.SetColourMode(colours)
+	return nil
+}
+
+func (t *TermboxScreen) Close() {
+	termbox.Close()
+}
+
+func (t *TermboxScreen) SetColourMode(colours ColourMode) {
+	t.trueColour = colours == ColourModeTrueColour
+	termbox.SetOutputMode(termboxOutputMode(colours))
+}
+
+func (t *TermboxScreen) Size() (int, int) {
+	return termbox.Size()
+}
+
+func (t *TermboxScreen) Clear(fg, bg Colour) {
+	termbox.Clear(fg.termboxAttribute(t.trueColour), bg.termboxAttribute(t.trueColour))
+}
+
+func (t *TermboxScreen) SetCell(x, y int, ch rune, fg, bg Colour) {
+	termbox.SetCell(x, y, ch, fg.termboxAttribute(t.trueColour),
+		bg.termboxAttribute(t.trueColour))
+}
+
+func (t *TermboxScreen) Flush() {
+	termbox.Flush()
+}
+
+func (t *TermboxScreen) PollEvent() Event {
+	ev := termbox.PollEvent()
+	switch ev.Type {
+	case termbox.EventKey:
+		event := Event{Type: EventKey, Key: KeyOther}
+		switch {
+		case ev.Key == termbox.KeyEsc:
+			event.Key = KeyEsc
+		case ev.Key == termbox.KeySpace:
+			event.Key = KeySpace
+		case ev.Ch != 0:
+			event.Key, event.Ch = KeyRune, ev.Ch
+		}
+		return event
+	case termbox.EventResize:
+		return Event{Type: EventResize, Width: ev.Width, Height: ev.Height}
+	case termbox.EventError:
+		return Event{Type: EventError, Err: ev.Err}
+	}
+	return Event{Type: EventNone}
+}
+
+// termboxOutputMode returns the termbox output mode for drawing in
+// colours, the 8 and 16 colour and monochrome modes all use the
+// terminal's standard colours.
+func termboxOutputMode(colours ColourMode) termbox.OutputMode {
+	switch colours {
+	case ColourModeTrueColour:
+		return termbox.OutputRGB
+	case ColourMode256:
+		return termbox.Output256
+	}
+	return termbox.OutputNormal
+}
+
+// termboxAttributes are the termbox attributes for each of the
+// attributes that can be added to a colour.
+var termboxAttributes = []struct {
+	attribute Colour
+	termbox   termbox.Attribute
+}{
+	{AttrBold, termbox.AttrBold},
+	{AttrUnderline, termbox.AttrUnderline},
+	{AttrReverse, termbox.AttrReverse},
+}
+
+// termboxAttribute returns the termbox attribute c is drawn with, as an
+// RGB colour if trueColour is set, otherwise from the 256 colour palette.
+func (c Colour) termboxAttribute(trueColour bool) termbox.Attribute {
+	attribute := termbox.ColorDefault
+	switch {
+	case c.isDefault():
+	case trueColour:
+		r, g, b := c.rgb()
+		attribute = termbox.RGBToAttribute(uint8(r), uint8(g), uint8(b))
+	default:
+		attribute = termbox.Attribute(c.quantise(256) & paletteMask)
+	}
+	for _, extra := range termboxAttributes {
+		if c&extra.attribute != 0 {
+			attribute |= extra.termbox
+		}
+	}
+	return attribute
+}
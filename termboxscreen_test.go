@@ -0,0 +1,27 @@
+package monitrondashboard
+
+import (
+	"github.com/nsf/termbox-go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestColoursConvertToTermboxAttributes(t *testing.T) {
+	orange := RGBColour(0xd7, 0x5f, 0x00) | AttrBold
+
+	assert.Equal(t, termbox.RGBToAttribute(0xd7, 0x5f, 0x00)|termbox.AttrBold,
+		orange.termboxAttribute(true))
+	assert.Equal(t, termbox.Attribute(167)|termbox.AttrBold, orange.termboxAttribute(false))
+	assert.Equal(t, termbox.RGBToAttribute(0xd7, 0x5f, 0x00),
+		xtermColour(166).termboxAttribute(true))
+	assert.Equal(t, termbox.ColorRed|termbox.AttrReverse,
+		(ColourRed | AttrReverse).termboxAttribute(false))
+	assert.Equal(t, termbox.ColorDefault, ColourDefault.termboxAttribute(true))
+}
+
+func TestTermboxOutputModeMatchesTheColourMode(t *testing.T) {
+	assert.Equal(t, termbox.OutputRGB, termboxOutputMode(ColourModeTrueColour))
+	assert.Equal(t, termbox.Output256, termboxOutputMode(ColourMode256))
+	assert.Equal(t, termbox.OutputNormal, termboxOutputMode(ColourMode16))
+	assert.Equal(t, termbox.OutputNormal, termboxOutputMode(ColourModeMonochrome))
+}
@@ -0,0 +1,119 @@
+package monitrondashboard
+
+// Plain text output for the monitron dashboard.
+// Here you'll find code for printing a summary of every build followed
+// by a line for each change, for terminals that can't handle termbox.
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TextPrinter prints a line per build for the first BuildUpdate received
+// from a BuildFetcher and then only the builds that change.
+type TextPrinter struct {
+	fetcher BuildFetcher
+	writer  io.Writer
+	builds  []build
+	err     error
+	started bool
+}
+
+// NewTextPrinter creates a TextPrinter that writes updates from fetcher
+// to writer.
+func NewTextPrinter(fetcher BuildFetcher, writer io.Writer) *TextPrinter {
+	return &TextPrinter{
+		fetcher: fetcher,
+		writer:  writer,
+		builds:  []build{},
+	}
+}
+
+// Run prints build updates as they arrive, returning only if writing
+// fails.
+func (p *TextPrinter) Run() error {
+	for buildUpdate := range p.fetcher.BuildChannel() {
+		if err := p.printBuildUpdate(buildUpdate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printBuildUpdate prints the lines for a single BuildUpdate. Errors are
+// printed once and the last good build list is kept, so builds are not
+// reported as removed and re-added each time the connection drops. The
+// builds in an update with an error, from the servers that are still
+// working, are printed as usual.
+func (p *TextPrinter) printBuildUpdate(buildUpdate BuildUpdate) error {
+	builds := buildUpdate.builds
+	if buildUpdate.err != nil {
+		if p.err == nil || p.err.Error() != buildUpdate.err.Error() {
+			p.err = buildUpdate.err
+			if _, err := fmt.Fprintf(p.writer, "Error: %s\n", buildUpdate.err); err != nil {
+				return err
+			}
+		}
+		if len(builds) == 0 {
+			return nil
+		}
+		builds = withMissingBuilds(p.builds, builds)
+	} else {
+		p.err = nil
+	}
+
+	lines := []string{}
+	if !p.started {
+		for _, build := range builds {
+			lines = append(lines,
+				fmt.Sprintf("%s: %s", build.name, describeBuild(build)))
+		}
+		p.started = true
+	} else {
+		for _, change := range diffBuilds(p.builds, builds) {
+			lines = append(lines, describeBuildChange(change))
+		}
+	}
+	p.builds = builds
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(p.writer, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describeBuild returns a short description of the state of a build,
+// failures are in upper case so they stand out in a log.
+func describeBuild(build build) string {
+	description := build.buildState.String()
+	if build.buildState == BuildStateFailed {
+		description = strings.ToUpper(description)
+	}
+	if build.acknowledger != "" {
+		description = fmt.Sprintf("%s by %s", description, build.acknowledger)
+	}
+	if build.building {
+		description = fmt.Sprintf("%s (%s)", description,
+			strings.ToLower(buildingMessage))
+	}
+	if build.escalated {
+		description = fmt.Sprintf("%s (escalated)", description)
+	}
+	return description
+}
+
+// describeBuildChange returns a line describing change.
+func describeBuildChange(change buildChange) string {
+	switch change.kind {
+	case buildAdded:
+		return fmt.Sprintf("%s: added, %s", change.name(),
+			describeBuild(change.current))
+	case buildRemoved:
+		return fmt.Sprintf("%s: removed", change.name())
+	}
+	return fmt.Sprintf("%s: %s → %s", change.name(),
+		describeBuild(change.previous), describeBuild(change.current))
+}
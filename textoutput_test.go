@@ -0,0 +1,82 @@
+package monitrondashboard
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTextPrinterPrintsEveryBuildForTheFirstUpdate(t *testing.T) {
+	var buffer bytes.Buffer
+	printer := NewTextPrinter(nil, &buffer)
+
+	assert.NoError(t, printer.printBuildUpdate(BuildUpdate{
+		builds: []build{
+			{name: "Build", buildState: BuildStatePassed, building: true},
+			{name: "Failing Build", buildState: BuildStateFailed},
+			{name: "Acknowledged Build", buildState: BuildStateAcknowledged, acknowledger: "Dave"},
+		},
+	}))
+
+	assert.Equal(t, "Build: passed (building)\n"+
+		"Failing Build: FAILED\n"+
+		"Acknowledged Build: acknowledged by Dave\n", buffer.String())
+}
+
+func TestTextPrinterOnlyPrintsChangesAfterTheFirstUpdate(t *testing.T) {
+	var buffer bytes.Buffer
+	printer := NewTextPrinter(nil, &buffer)
+	printer.printBuildUpdate(BuildUpdate{
+		builds: []build{
+			{name: "payments-api", buildState: BuildStatePassed},
+			{name: "web", buildState: BuildStatePassed},
+		},
+	})
+	buffer.Reset()
+
+	assert.NoError(t, printer.printBuildUpdate(BuildUpdate{
+		builds: []build{
+			{name: "payments-api", buildState: BuildStateFailed},
+			{name: "web", buildState: BuildStatePassed},
+		},
+	}))
+
+	assert.Equal(t, "payments-api: passed → FAILED\n", buffer.String())
+}
+
+func TestTextPrinterPrintsAnErrorOnceAndKeepsBuilds(t *testing.T) {
+	var buffer bytes.Buffer
+	printer := NewTextPrinter(nil, &buffer)
+	builds := []build{{name: "web", buildState: BuildStatePassed}}
+	networkError := BuildUpdate{
+		builds: []build{},
+		err:    buildError{BuildErrorNetwork, "Network Error"},
+	}
+
+	printer.printBuildUpdate(BuildUpdate{builds: builds})
+	printer.printBuildUpdate(networkError)
+	printer.printBuildUpdate(networkError)
+	printer.printBuildUpdate(BuildUpdate{builds: builds})
+
+	assert.Equal(t, "web: passed\nError: Network Error\n", buffer.String())
+}
+
+func TestTextPrinterPrintsTheBuildsOfWorkingServersWhenAnotherFails(t *testing.T) {
+	var buffer bytes.Buffer
+	printer := NewTextPrinter(nil, &buffer)
+	printer.printBuildUpdate(BuildUpdate{builds: []build{
+		{name: "payments-api", buildState: BuildStatePassed, source: "ci"},
+		{name: "search", buildState: BuildStatePassed, source: "release"},
+	}})
+	buffer.Reset()
+
+	assert.NoError(t, printer.printBuildUpdate(BuildUpdate{
+		builds: []build{
+			{name: "payments-api", buildState: BuildStateFailed, source: "ci"},
+		},
+		err: buildError{BuildErrorNetwork, "release: Network Error"},
+	}))
+
+	assert.Equal(t, "Error: release: Network Error\n"+
+		"payments-api: passed → FAILED\n", buffer.String())
+}
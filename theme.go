@@ -0,0 +1,244 @@
+package monitrondashboard
+
+// Themes for the monitron dashboard.
+// Here you'll find the colours and attributes each part of the dashboard
+// is drawn with, the built in themes and the code for reading themes from
+// the config file.
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Style is the foreground and background a part of the dashboard is
+// drawn with, attributes such as bold are added to the foreground.
+type Style struct {
+	Fg Colour
+	Bg Colour
+}
+
+// Theme is the Style each part of the dashboard is drawn with.
+type Theme struct {
+	// Failed, Acknowledged, Passed and Unknown colour the state swatch
+	// in each build box.
+	Failed       Style `yaml:"failed"`
+	Acknowledged Style `yaml:"acknowledged"`
+	Passed       Style `yaml:"passed"`
+	Unknown      Style `yaml:"unknown"`
+	// Building is the building indicator.
+	Building Style `yaml:"building"`
+	Title    Style `yaml:"title"`
+	// Border is the build box borders, which are drawn over the Text
+	// background so only the foreground is used.
+	Border Style `yaml:"border"`
+	// Text is the build name and acknowledger, and the background of
+	// the screen.
+	Text  Style `yaml:"text"`
+	Error Style `yaml:"error"`
+}
+
+// stateStyle returns the style of the swatch for a build in state.
+func (t Theme) stateStyle(state buildState) Style {
+	switch state {
+	case BuildStateFailed:
+		return t.Failed
+	case BuildStateAcknowledged:
+		return t.Acknowledged
+	case BuildStatePassed:
+		return t.Passed
+	}
+	return t.Unknown
+}
+
+// DefaultTheme is the theme used unless another is chosen.
+var DefaultTheme = Theme{
+	Failed:       Style{ColourWhite, ColourRed},
+	Acknowledged: Style{ColourWhite, xtermColour(166)},
+	Passed:       Style{ColourWhite, ColourGreen},
+	Unknown:      Style{ColourWhite, ColourMagenta},
+	Building:     Style{ColourWhite, ColourBlack},
+	Title:        Style{ColourWhite, ColourBlack},
+	Border:       Style{ColourWhite, ColourBlack},
+	Text:         Style{ColourWhite, ColourBlack},
+	Error:        Style{ColourWhite, ColourBlack},
+}
+
+// BuiltinThemes are the themes that can be chosen by name without being
+// defined in the config file.
+var BuiltinThemes = map[string]Theme{
+	"default": DefaultTheme,
+	"high-contrast": {
+		Failed:       Style{xtermColour(231) | AttrBold, xtermColour(196)},
+		Acknowledged: Style{xtermColour(16) | AttrBold, xtermColour(226)},
+		Passed:       Style{xtermColour(16) | AttrBold, xtermColour(46)},
+		Unknown:      Style{xtermColour(16) | AttrBold, xtermColour(201)},
+		Building:     Style{xtermColour(226) | AttrBold, xtermColour(16)},
+		Title:        Style{xtermColour(231) | AttrBold, xtermColour(16)},
+		Border:       Style{xtermColour(231) | AttrBold, xtermColour(16)},
+		Text:         Style{xtermColour(231) | AttrBold, xtermColour(16)},
+		Error:        Style{xtermColour(196) | AttrBold, xtermColour(16)},
+	},
+	"solarized": {
+		Failed:       Style{xtermColour(230), xtermColour(160)},
+		Acknowledged: Style{xtermColour(230), xtermColour(166)},
+		Passed:       Style{xtermColour(230), xtermColour(64)},
+		Unknown:      Style{xtermColour(230), xtermColour(125)},
+		Building:     Style{xtermColour(136), xtermColour(234)},
+		Title:        Style{xtermColour(33) | AttrBold, xtermColour(234)},
+		Border:       Style{xtermColour(240), xtermColour(234)},
+		Text:         Style{xtermColour(244), xtermColour(234)},
+		Error:        Style{xtermColour(160), xtermColour(234)},
+	},
+	// colour-blind uses colours from the Okabe-Ito palette, which can be
+	// told apart with the common kinds of colour blindness.
+	"colour-blind": {
+		Failed:       Style{xtermColour(231) | AttrBold, xtermColour(166)},
+		Acknowledged: Style{xtermColour(16) | AttrBold, xtermColour(221)},
+		Passed:       Style{xtermColour(231) | AttrBold, xtermColour(25)},
+		Unknown:      Style{xtermColour(16) | AttrBold, xtermColour(175)},
+		Building:     Style{xtermColour(74) | AttrBold, ColourBlack},
+		Title:        Style{ColourWhite, ColourBlack},
+		Border:       Style{ColourWhite, ColourBlack},
+		Text:         Style{ColourWhite, ColourBlack},
+		Error:        Style{xtermColour(166) | AttrBold, ColourBlack},
+	},
+	"light-background": {
+		Failed:       Style{xtermColour(231), xtermColour(160)},
+		Acknowledged: Style{xtermColour(16), xtermColour(214)},
+		Passed:       Style{xtermColour(231), xtermColour(28)},
+		Unknown:      Style{xtermColour(231), xtermColour(127)},
+		Building:     Style{xtermColour(19) | AttrBold, ColourDefault},
+		Title:        Style{xtermColour(16) | AttrBold, ColourDefault},
+		Border:       Style{xtermColour(240), ColourDefault},
+		Text:         Style{xtermColour(16), ColourDefault},
+		Error:        Style{xtermColour(160) | AttrBold, ColourDefault},
+	},
+}
+
+// themeNames returns the names of the built in themes and those in
+// themes, sorted.
+func themeNames(themes map[string]Theme) []string {
+	names := []string{}
+	for name := range BuiltinThemes {
+		names = append(names, name)
+	}
+	for name := range themes {
+		if _, ok := BuiltinThemes[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// colourNames are the names colours can be given by in the config file.
+var colourNames = map[string]Colour{
+	"default": ColourDefault,
+	"black":   ColourBlack,
+	"red":     ColourRed,
+	"green":   ColourGreen,
+	"yellow":  ColourYellow,
+	"blue":    ColourBlue,
+	"magenta": ColourMagenta,
+	"cyan":    ColourCyan,
+	"white":   ColourWhite,
+}
+
+// attributeNames are the names of the attributes that can be added to a
+// colour in the config file.
+var attributeNames = map[string]Colour{
+	"bold":      AttrBold,
+	"underline": AttrUnderline,
+	"reverse":   AttrReverse,
+}
+
+// parseColour parses a colour from the config file, a colour name, xterm
+// palette index or #rrggbb hex colour followed by any attributes, e.g.
+// "white bold", "166 underline" or "#d75f00".
+func parseColour(colour string) (Colour, error) {
+	words := strings.Fields(colour)
+	if len(words) == 0 {
+		return 0, fmt.Errorf("missing colour")
+	}
+
+	attribute, ok := colourNames[words[0]]
+	if !ok {
+		var err error
+		if attribute, err = parseColourValue(words[0]); err != nil {
+			return 0, err
+		}
+	}
+	for _, word := range words[1:] {
+		extra, ok := attributeNames[word]
+		if !ok {
+			return 0, fmt.Errorf("unknown attribute %q, expected bold, underline or reverse", word)
+		}
+		attribute |= extra
+	}
+	return attribute, nil
+}
+
+// parseColourValue parses a colour given as an xterm palette index or a
+// #rrggbb hex colour.
+func parseColourValue(value string) (Colour, error) {
+	if strings.HasPrefix(value, "#") {
+		rgb, err := strconv.ParseUint(value[1:], 16, 32)
+		if err != nil || len(value) != 7 {
+			return 0, fmt.Errorf("bad hex colour %q, expected #rrggbb", value)
+		}
+		return RGBColour(uint8(rgb>>16), uint8(rgb>>8), uint8(rgb)), nil
+	}
+	index, err := strconv.Atoi(value)
+	if err != nil || index < 0 || index > 255 {
+		return 0, fmt.Errorf("unknown colour %q, expected a colour name, 0 to 255 or #rrggbb", value)
+	}
+	return xtermColour(index), nil
+}
+
+// UnmarshalYAML decodes a theme, starting from the built in theme named
+// by base, or the default theme, and changing the styles it gives.
+func (t *Theme) UnmarshalYAML(value *yaml.Node) error {
+	if err := checkFields(value, append(structFields(t), "base")); err != nil {
+		return err
+	}
+	*t = DefaultTheme
+	if baseNode := mappingValue(value, "base"); baseNode != nil {
+		base, err := decodeChoice(baseNode, "base theme", themeNames(nil))
+		if err != nil {
+			return err
+		}
+		*t = BuiltinThemes[base]
+	}
+
+	type plainTheme Theme
+	return value.Decode((*plainTheme)(t))
+}
+
+// UnmarshalYAML decodes a style's fg and bg colours, keeping the current
+// colour for either that isn't given.
+func (s *Style) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return configError(value, "expected a style with fg and bg colours")
+	}
+	if err := checkFields(value, []string{"fg", "bg"}); err != nil {
+		return err
+	}
+	for _, colour := range []struct {
+		key       string
+		attribute *Colour
+	}{{"fg", &s.Fg}, {"bg", &s.Bg}} {
+		colourNode := mappingValue(value, colour.key)
+		if colourNode == nil {
+			continue
+		}
+		attribute, err := parseColour(colourNode.Value)
+		if err != nil {
+			return configError(colourNode, "%s", err)
+		}
+		*colour.attribute = attribute
+	}
+	return nil
+}
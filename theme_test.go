@@ -0,0 +1,115 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var parseColourTests = []struct {
+	in  string
+	out Colour
+	err string
+}{
+	{"red", ColourRed, ""},
+	{"default", ColourDefault, ""},
+	{"166", xtermColour(166), ""},
+	{"white bold", ColourWhite | AttrBold, ""},
+	{"0 underline reverse", xtermColour(0) | AttrUnderline | AttrReverse, ""},
+	{"#d75f00", RGBColour(0xd7, 0x5f, 0x00), ""},
+	{"#00A3E0 bold", RGBColour(0x00, 0xa3, 0xe0) | AttrBold, ""},
+	{"#fff", 0, `bad hex colour "#fff", expected #rrggbb`},
+	{"#gg0000", 0, `bad hex colour "#gg0000", expected #rrggbb`},
+	{"", 0, "missing colour"},
+	{"orange", 0, `unknown colour "orange", expected a colour name, 0 to 255 or #rrggbb`},
+	{"256", 0, `unknown colour "256", expected a colour name, 0 to 255 or #rrggbb`},
+	{"red blink", 0, `unknown attribute "blink", expected bold, underline or reverse`},
+}
+
+func TestParseColour(t *testing.T) {
+	for _, test := range parseColourTests {
+		colour, err := parseColour(test.in)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.in)
+			continue
+		}
+		assert.Nil(t, err, test.in)
+		assert.Equal(t, test.out, colour, test.in)
+	}
+}
+
+func TestParseConfigReadsUserThemesStartingFromTheirBase(t *testing.T) {
+	config := `
+theme: wall
+themes:
+  wall:
+    base: solarized
+    failed: {bg: 196}
+    title: {fg: "white bold"}
+`
+	settings, err := parseConfig([]byte(config), "")
+
+	assert.Nil(t, err)
+	theme := settings.CurrentTheme()
+	solarized := BuiltinThemes["solarized"]
+	assert.Equal(t, Style{solarized.Failed.Fg, xtermColour(196)}, theme.Failed)
+	assert.Equal(t, Style{ColourWhite | AttrBold, solarized.Title.Bg},
+		theme.Title)
+	assert.Equal(t, solarized.Passed, theme.Passed)
+}
+
+func TestCurrentThemeChoosesBuiltinThemes(t *testing.T) {
+	assert.Equal(t, DefaultTheme, Settings{}.CurrentTheme())
+	assert.Equal(t, BuiltinThemes["light-background"],
+		Settings{Theme: "light-background"}.CurrentTheme())
+}
+
+var invalidThemeTests = []struct {
+	config string
+	err    string
+}{
+	{"theme: neon\n",
+		`line 1: unknown theme "neon", expected one of [colour-blind default high-contrast light-background solarized]`},
+	{"profiles:\n  wall:\n    theme: neon\n",
+		`line 3: unknown theme "neon", expected one of [colour-blind default high-contrast light-background solarized]`},
+	{"themes:\n  wall:\n    base: neon\n",
+		`line 3: unknown base theme "neon", expected one of [colour-blind default high-contrast light-background solarized]`},
+	{"themes:\n  wall:\n    titel: {fg: red}\n",
+		`line 3: unknown field "titel", expected one of [failed acknowledged passed unknown building title border text error base]`},
+	{"themes:\n  wall:\n    title: red\n", "line 3: expected a style with fg and bg colours"},
+	{"themes:\n  wall:\n    title: {fg: orange}\n",
+		`line 3: unknown colour "orange", expected a colour name, 0 to 255 or #rrggbb`},
+}
+
+func TestParseConfigGivesLineNumbersForInvalidThemes(t *testing.T) {
+	for _, test := range invalidThemeTests {
+		_, err := parseConfig([]byte(test.config), "wall")
+		assert.EqualError(t, err, test.err, test.config)
+	}
+}
+
+func TestDrawingABuildUsesTheTheme(t *testing.T) {
+	screen := NewVirtualScreen(40, 10)
+	dashboard := NewDashboard(nil, screen)
+	dashboard.ApplySettings(Settings{Theme: "solarized", Colours: ColourMode256,
+		Layout: DefaultLayout})
+	solarized := BuiltinThemes["solarized"]
+
+	dashboard.drawWidget(dashboard.buildBox(build{
+		name:       "Test Build",
+		buildState: BuildStatePassed,
+		building:   true,
+	}), NewRect(0, 0, 30, 4))
+
+	assertCellColours(t, screen, 0, 0, solarized.Border.Fg, solarized.Text.Bg,
+		"the border colour", "the text background")
+	assertCellColours(t, screen, 2, 1, solarized.Text.Fg, solarized.Passed.Bg,
+		"the text colour", "the passed colour")
+	assertCellColours(t, screen, 11, 1, solarized.Text.Fg, solarized.Text.Bg,
+		"the text colour", "the text background")
+	assertCellColours(t, screen, 11, 2, solarized.Building.Fg, solarized.Building.Bg,
+		"the building colour", "the building background")
+	assertCellColours(t, screen, 18, 2, solarized.Building.Fg, solarized.Building.Bg,
+		"the building colour", "the building background")
+	assertCellColours(t, screen, 19, 2, solarized.Text.Fg, solarized.Text.Bg,
+		"the text colour", "the text background")
+}
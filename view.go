@@ -0,0 +1,233 @@
+package monitrondashboard
+
+// Views of the builds for the monitron dashboard.
+// Here you'll find code for filtering, sorting and grouping the builds
+// before they are shown, so that each dashboard can show just the
+// builds it cares about.
+
+import (
+	"path"
+	"sort"
+)
+
+// SortOrder is the order builds are shown in.
+type SortOrder string
+
+const (
+	// SortByServer keeps builds in the order the server sends them.
+	SortByServer SortOrder = "server"
+	// SortByName sorts builds alphabetically by name.
+	SortByName SortOrder = "name"
+	// SortByState shows failed builds first, then acknowledged, passed
+	// and unknown builds.
+	SortByState SortOrder = "state"
+	// SortByFailingSince shows the builds that have been failing longest
+	// first.
+	SortByFailingSince SortOrder = "failing-since"
+)
+
+// sortOrders are the known SortOrders.
+var sortOrders = []string{
+	string(SortByServer),
+	string(SortByName),
+	string(SortByState),
+	string(SortByFailingSince),
+}
+
+// Pattern is a shell pattern matched against build names, e.g. payments-*.
+type Pattern string
+
+// matches returns true if name matches the pattern.
+func (p Pattern) matches(name string) bool {
+	matched, _ := path.Match(string(p), name)
+	return matched
+}
+
+// Filter picks which builds are shown.
+type Filter struct {
+	// Include shows only the builds matching one of these patterns, if
+	// there are any.
+	Include []Pattern `yaml:"include"`
+	// Exclude hides the builds matching any of these patterns.
+	Exclude []Pattern `yaml:"exclude"`
+	// States shows only the builds in one of these states, if there are
+	// any.
+	States []buildState `yaml:"states"`
+}
+
+// Group is a set of builds that are sorted next to each other.
+type Group struct {
+	Builds []Pattern `yaml:"builds"`
+}
+
+// View is how builds are filtered, sorted and grouped before they are
+// shown.
+type View struct {
+	Filter Filter    `yaml:"filter"`
+	Sort   SortOrder `yaml:"sort"`
+	// Groups are sorted in order, builds in no group are sorted last.
+	// Escalated builds are still shown first.
+	Groups []Group `yaml:"groups"`
+}
+
+// apply returns the builds the view shows, in the order it shows them.
+func (v View) apply(builds []build) []build {
+	shown := make([]build, 0, len(builds))
+	for _, build := range builds {
+		if v.Filter.matches(build) {
+			shown = append(shown, build)
+		}
+	}
+
+	switch v.Sort {
+	case SortByName:
+		sort.Stable(buildsBy{shown, func(a, b build) bool {
+			return a.name < b.name
+		}})
+	case SortByState:
+		sort.Stable(buildsBy{shown, func(a, b build) bool {
+			return a.buildState < b.buildState
+		}})
+	case SortByFailingSince:
+		sort.Stable(buildsBy{shown, func(a, b build) bool {
+			if a.failingSince.IsZero() || b.failingSince.IsZero() {
+				return !a.failingSince.IsZero()
+			}
+			return a.failingSince.Before(b.failingSince)
+		}})
+	}
+
+	if len(v.Groups) > 0 {
+		sort.Stable(buildsBy{shown, func(a, b build) bool {
+			return v.groupIndex(a) < v.groupIndex(b)
+		}})
+	}
+	return shown
+}
+
+// groupIndex returns the index of the first group build is in, or the
+// number of groups if it isn't in any.
+func (v View) groupIndex(build build) int {
+	for i, group := range v.Groups {
+		for _, pattern := range group.Builds {
+			if pattern.matches(build.name) {
+				return i
+			}
+		}
+	}
+	return len(v.Groups)
+}
+
+// matches returns true if the filter shows build.
+func (f Filter) matches(build build) bool {
+	if len(f.Include) > 0 && !anyPatternMatches(f.Include, build.name) {
+		return false
+	}
+	if anyPatternMatches(f.Exclude, build.name) {
+		return false
+	}
+	if len(f.States) == 0 {
+		return true
+	}
+	for _, state := range f.States {
+		if build.buildState == state {
+			return true
+		}
+	}
+	return false
+}
+
+// anyPatternMatches returns true if any of patterns match name.
+func anyPatternMatches(patterns []Pattern, name string) bool {
+	for _, pattern := range patterns {
+		if pattern.matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildsBy is a sort interface for a []build that sorts using less.
+type buildsBy struct {
+	builds []build
+	less   func(a, b build) bool
+}
+
+func (s buildsBy) Len() int {
+	return len(s.builds)
+}
+
+func (s buildsBy) Swap(i, j int) {
+	s.builds[i], s.builds[j] = s.builds[j], s.builds[i]
+}
+
+func (s buildsBy) Less(i, j int) bool {
+	return s.less(s.builds[i], s.builds[j])
+}
+
+// ViewFetcher is a BuildFetcher that wraps another BuildFetcher, passing
+// on only the builds a View shows in the order it shows them.
+type ViewFetcher struct {
+	fetcher      BuildFetcher
+	view         View
+	buildChannel chan BuildUpdate
+	views        chan View
+	done         chan bool
+}
+
+// NewViewFetcher creates a ViewFetcher showing the builds from fetcher
+// through view.
+func NewViewFetcher(fetcher BuildFetcher, view View) *ViewFetcher {
+	viewFetcher := &ViewFetcher{
+		fetcher:      fetcher,
+		view:         view,
+		buildChannel: make(chan BuildUpdate),
+		views:        make(chan View),
+		done:         make(chan bool),
+	}
+	go viewFetcher.forwardBuilds()
+	return viewFetcher
+}
+
+func (v *ViewFetcher) BuildChannel() chan BuildUpdate {
+	return v.buildChannel
+}
+
+// SetView changes the view, resending the latest update through it
+// unless the wrapped fetcher has finished.
+func (v *ViewFetcher) SetView(view View) {
+	select {
+	case v.views <- view:
+	case <-v.done:
+	}
+}
+
+// forwardBuilds passes on every update from the wrapped fetcher through
+// the view.
+func (v *ViewFetcher) forwardBuilds() {
+	var latest *BuildUpdate
+	for {
+		select {
+		case buildUpdate, ok := <-v.fetcher.BuildChannel():
+			if !ok {
+				close(v.done)
+				close(v.buildChannel)
+				return
+			}
+			latest = &buildUpdate
+			v.buildChannel <- v.apply(buildUpdate)
+		case view := <-v.views:
+			v.view = view
+			if latest != nil {
+				v.buildChannel <- v.apply(*latest)
+			}
+		}
+	}
+}
+
+// apply returns buildUpdate with the builds the view shows, which may
+// come with an error from one of several servers.
+func (v *ViewFetcher) apply(buildUpdate BuildUpdate) BuildUpdate {
+	buildUpdate.builds = v.view.apply(buildUpdate.builds)
+	return buildUpdate
+}
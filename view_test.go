@@ -0,0 +1,100 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// buildNames returns the names of builds in order.
+func buildNames(builds []build) []string {
+	names := make([]string, len(builds))
+	for i, build := range builds {
+		names[i] = build.name
+	}
+	return names
+}
+
+var viewBuilds = []build{
+	{name: "payments-api", buildState: BuildStatePassed},
+	{name: "search", buildState: BuildStateFailed,
+		failingSince: time.Unix(1425590000, 0)},
+	{name: "payments-nightly", buildState: BuildStateAcknowledged},
+	{name: "billing", buildState: BuildStateFailed,
+		failingSince: time.Unix(1425580000, 0)},
+}
+
+var viewTests = []struct {
+	view   View
+	builds []string
+}{
+	{View{},
+		[]string{"payments-api", "search", "payments-nightly", "billing"}},
+	{View{Filter: Filter{Include: []Pattern{"payments-*"}}},
+		[]string{"payments-api", "payments-nightly"}},
+	{View{Filter: Filter{Exclude: []Pattern{"*-nightly", "search"}}},
+		[]string{"payments-api", "billing"}},
+	{View{Filter: Filter{States: []buildState{BuildStateFailed}}},
+		[]string{"search", "billing"}},
+	{View{Sort: SortByName},
+		[]string{"billing", "payments-api", "payments-nightly", "search"}},
+	{View{Sort: SortByState},
+		[]string{"search", "billing", "payments-nightly", "payments-api"}},
+	{View{Sort: SortByFailingSince},
+		[]string{"billing", "search", "payments-api", "payments-nightly"}},
+	{View{Sort: SortByName, Groups: []Group{
+		{Builds: []Pattern{"search"}},
+		{Builds: []Pattern{"payments-*"}},
+	}},
+		[]string{"search", "payments-api", "payments-nightly", "billing"}},
+}
+
+func TestViewApply(t *testing.T) {
+	for _, test := range viewTests {
+		assert.Equal(t, test.builds, buildNames(test.view.apply(viewBuilds)),
+			"%+v", test.view)
+	}
+}
+
+func TestViewFetcherAppliesTheViewToUpdates(t *testing.T) {
+	fetcher := stubBuildFetcher{make(chan BuildUpdate)}
+	viewFetcher := NewViewFetcher(fetcher, View{Sort: SortByName})
+
+	go func() {
+		fetcher.buildChannel <- BuildUpdate{builds: viewBuilds}
+		fetcher.buildChannel <- BuildUpdate{builds: viewBuilds[1:],
+			err: buildError{BuildErrorNetwork, "Network Error"}}
+		close(fetcher.buildChannel)
+	}()
+
+	update := <-viewFetcher.BuildChannel()
+	assert.Equal(t, []string{"billing", "payments-api", "payments-nightly", "search"},
+		buildNames(update.builds))
+	update = <-viewFetcher.BuildChannel()
+	assert.EqualError(t, update.err, "Network Error")
+	assert.Equal(t, []string{"billing", "payments-nightly", "search"},
+		buildNames(update.builds), "Builds sent with an error should be in the view too")
+	_, ok := <-viewFetcher.BuildChannel()
+	assert.False(t, ok, "The channel should close with the wrapped fetcher's")
+}
+
+func TestViewFetcherResendsTheLatestUpdateWhenTheViewChanges(t *testing.T) {
+	fetcher := stubBuildFetcher{make(chan BuildUpdate)}
+	viewFetcher := NewViewFetcher(fetcher, View{Sort: SortByName})
+	fetcher.buildChannel <- BuildUpdate{builds: viewBuilds}
+	<-viewFetcher.BuildChannel()
+
+	go viewFetcher.SetView(View{Filter: Filter{States: []buildState{BuildStateFailed}}})
+
+	update := <-viewFetcher.BuildChannel()
+	assert.Equal(t, []string{"search", "billing"}, buildNames(update.builds))
+}
+
+func TestViewFetcherIgnoresViewChangesOnceFinished(t *testing.T) {
+	fetcher := stubBuildFetcher{make(chan BuildUpdate)}
+	viewFetcher := NewViewFetcher(fetcher, View{})
+	close(fetcher.buildChannel)
+	<-viewFetcher.BuildChannel()
+
+	viewFetcher.SetView(View{Sort: SortByName})
+}
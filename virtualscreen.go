@@ -0,0 +1,200 @@
+package monitrondashboard
+
+// The virtual screen for the monitron dashboard.
+// Here you'll find a Screen that is held in memory rather than drawn on
+// a terminal, for testing what the dashboard draws and for rendering it
+// into other outputs.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Cell is a character drawn on a VirtualScreen with its colours.
+type Cell struct {
+	Ch rune
+	Fg Colour
+	Bg Colour
+}
+
+// blankCell is an empty cell in the default colours.
+var blankCell = Cell{Ch: ' '}
+
+// MarshalJSON encodes the cell with its character as a string and its
+// colours as they are written in the config file.
+func (c Cell) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Ch string `json:"ch"`
+		Fg Colour `json:"fg"`
+		Bg Colour `json:"bg"`
+	}{string(c.Ch), c.Fg, c.Bg})
+}
+
+// VirtualScreen is a Screen held in memory. Everything drawn on it can be
+// read back as plain text, text with ANSI colour codes or a grid of
+// cells.
+type VirtualScreen struct {
+	width   int
+	height  int
+	cells   []Cell
+	colours ColourMode
+	events  chan Event
+}
+
+// NewVirtualScreen creates a blank VirtualScreen of width by height
+// cells, drawing in 256 colours.
+func NewVirtualScreen(width, height int) *VirtualScreen {
+	screen := &VirtualScreen{
+		colours: ColourMode256,
+		events:  make(chan Event),
+	}
+	screen.Resize(width, height)
+	return screen
+}
+
+// Resize changes the size of the screen, keeping the cells that still
+// fit and blanking any new ones.
+func (v *VirtualScreen) Resize(width, height int) {
+	cells := make([]Cell, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			cells[y*width+x] = v.Cell(x, y)
+		}
+	}
+	v.width, v.height, v.cells = width, height, cells
+}
+
+// Cell returns the cell at x, y, or a blank cell if it is off the screen.
+func (v *VirtualScreen) Cell(x, y int) Cell {
+	if x < 0 || y < 0 || x >= v.width || y >= v.height {
+		return blankCell
+	}
+	return v.cells[y*v.width+x]
+}
+
+// PostEvent sends event to the dashboard polling the screen, waiting
+// until it has been received.
+func (v *VirtualScreen) PostEvent(event Event) {
+	v.events <- event
+}
+
+func (v *VirtualScreen) Init(colours ColourMode) error {
+	v.colours = colours
+	return nil
+}
+
+func (v *VirtualScreen) Close() {
+}
+
+func (v *VirtualScreen) SetColourMode(colours ColourMode) {
+	v.colours = colours
+}
+
+func (v *VirtualScreen) Size() (int, int) {
+	return v.width, v.height
+}
+
+func (v *VirtualScreen) Clear(fg, bg Colour) {
+	for i := range v.cells {
+		v.cells[i] = Cell{Ch: ' ', Fg: fg, Bg: bg}
+	}
+}
+
+// SetCell draws ch at x, y, cells off the screen are ignored as they
+// would be on a terminal.
+func (v *VirtualScreen) SetCell(x, y int, ch rune, fg, bg Colour) {
+	if x < 0 || y < 0 || x >= v.width || y >= v.height {
+		return
+	}
+	v.cells[y*v.width+x] = Cell{Ch: ch, Fg: fg, Bg: bg}
+}
+
+func (v *VirtualScreen) Flush() {
+}
+
+func (v *VirtualScreen) PollEvent() Event {
+	return <-v.events
+}
+
+// Text returns the characters on the screen, a line per row with
+// trailing spaces removed.
+func (v *VirtualScreen) Text() string {
+	var buffer bytes.Buffer
+	for y := 0; y < v.height; y++ {
+		row := make([]rune, v.width)
+		for x := range row {
+			row[x] = v.Cell(x, y).Ch
+		}
+		buffer.WriteString(strings.TrimRight(string(row), " "))
+		buffer.WriteRune('\n')
+	}
+	return buffer.String()
+}
+
+// ANSI returns the screen as text with ANSI escape codes for the colours
+// of each cell, as they would be drawn in the screen's colour mode.
+func (v *VirtualScreen) ANSI() string {
+	var buffer bytes.Buffer
+	for y := 0; y < v.height; y++ {
+		var last *Cell
+		for x := 0; x < v.width; x++ {
+			cell := v.Cell(x, y)
+			if last == nil || cell.Fg != last.Fg || cell.Bg != last.Bg {
+				buffer.WriteString(ansiStyle(cell.Fg, cell.Bg, v.colours))
+			}
+			buffer.WriteRune(cell.Ch)
+			last = &cell
+		}
+		buffer.WriteString("\x1b[0m\n")
+	}
+	return buffer.String()
+}
+
+// Grid returns a copy of the cells on the screen, a slice per row.
+func (v *VirtualScreen) Grid() [][]Cell {
+	grid := make([][]Cell, v.height)
+	for y := range grid {
+		grid[y] = append([]Cell{}, v.cells[y*v.width:(y+1)*v.width]...)
+	}
+	return grid
+}
+
+// ansiStyle returns the ANSI escape code that resets the style and draws
+// with fg and bg in colours.
+func ansiStyle(fg, bg Colour, colours ColourMode) string {
+	codes := []string{"0"}
+	if colours != ColourModeMonochrome {
+		codes = append(codes, ansiColour(fg, 30, colours), ansiColour(bg, 40, colours))
+	}
+	if fg&AttrBold != 0 {
+		codes = append(codes, "1")
+	}
+	if fg&AttrUnderline != 0 {
+		codes = append(codes, "4")
+	}
+	if (fg|bg)&AttrReverse != 0 {
+		codes = append(codes, "7")
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// ansiColour returns the ANSI code for drawing c in colours, base is 30
+// for the foreground and 40 for the background.
+func ansiColour(c Colour, base int, colours ColourMode) string {
+	switch {
+	case c.isDefault():
+		return fmt.Sprint(base + 9)
+	case colours == ColourModeTrueColour:
+		r, g, b := c.rgb()
+		return fmt.Sprintf("%d;2;%d;%d;%d", base+8, r, g, b)
+	case colours == ColourMode16 || colours == ColourMode8:
+		index := int(c.quantise(colours.paletteSize())&paletteMask) - 1
+		if index >= 8 {
+			return fmt.Sprint(base + 60 + index - 8)
+		}
+		return fmt.Sprint(base + index)
+	}
+	return fmt.Sprintf("%d;5;%d", base+8, int(c.quantise(256)&paletteMask)-1)
+}
@@ -0,0 +1,81 @@
+package monitrondashboard
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVirtualScreenIgnoresCellsOffTheScreen(t *testing.T) {
+	screen := NewVirtualScreen(3, 2)
+
+	screen.SetCell(1, 0, 'a', ColourRed, ColourBlack)
+	screen.SetCell(3, 0, 'b', ColourRed, ColourBlack)
+	screen.SetCell(0, -1, 'c', ColourRed, ColourBlack)
+
+	assert.Equal(t, " a\n\n", screen.Text())
+	assert.Equal(t, Cell{'a', ColourRed, ColourBlack}, screen.Cell(1, 0))
+	assert.Equal(t, blankCell, screen.Cell(3, 0))
+}
+
+func TestResizingAVirtualScreenKeepsTheCellsThatFit(t *testing.T) {
+	screen := NewVirtualScreen(3, 2)
+	screen.SetCell(0, 0, 'a', ColourDefault, ColourDefault)
+	screen.SetCell(2, 1, 'b', ColourDefault, ColourDefault)
+
+	screen.Resize(2, 3)
+
+	width, height := screen.Size()
+	assert.Equal(t, 2, width)
+	assert.Equal(t, 3, height)
+	assert.Equal(t, "a\n\n\n", screen.Text())
+}
+
+func TestClearingAVirtualScreenBlanksEveryCell(t *testing.T) {
+	screen := NewVirtualScreen(2, 1)
+	screen.SetCell(0, 0, 'a', ColourRed, ColourBlack)
+
+	screen.Clear(ColourWhite, ColourBlue)
+
+	assert.Equal(t, [][]Cell{{{' ', ColourWhite, ColourBlue}, {' ', ColourWhite, ColourBlue}}},
+		screen.Grid())
+}
+
+var virtualScreenANSITests = []struct {
+	colours ColourMode
+	ansi    string
+}{
+	{ColourMode256, "\x1b[0;38;5;7;48;5;166;1m!\x1b[0;39;49m \x1b[0m\n"},
+	{ColourMode16, "\x1b[0;37;43;1m!\x1b[0;39;49m \x1b[0m\n"},
+	{ColourModeTrueColour, "\x1b[0;38;2;192;192;192;48;2;215;95;0;1m!\x1b[0;39;49m \x1b[0m\n"},
+	{ColourModeMonochrome, "\x1b[0;1m!\x1b[0m \x1b[0m\n"},
+}
+
+func TestVirtualScreenANSIUsesItsColourMode(t *testing.T) {
+	for _, test := range virtualScreenANSITests {
+		screen := NewVirtualScreen(2, 1)
+		screen.SetColourMode(test.colours)
+		screen.SetCell(0, 0, '!', ColourWhite|AttrBold, xtermColour(166))
+
+		assert.Equal(t, test.ansi, screen.ANSI(), "%s", test.colours)
+	}
+}
+
+func TestVirtualScreenGridEncodesAsJSON(t *testing.T) {
+	screen := NewVirtualScreen(2, 1)
+	screen.SetCell(0, 0, '✗', ColourWhite|AttrBold, RGBColour(0xd7, 0x5f, 0x00))
+
+	encoded, err := json.Marshal(screen.Grid())
+
+	assert.Nil(t, err)
+	assert.Equal(t, `[[{"ch":"✗","fg":"white bold","bg":"#d75f00"},`+
+		`{"ch":" ","fg":"default","bg":"default"}]]`, string(encoded))
+}
+
+func TestVirtualScreenPollsThePostedEvents(t *testing.T) {
+	screen := NewVirtualScreen(2, 1)
+
+	go screen.PostEvent(Event{Type: EventKey, Key: KeyRune, Ch: 'q'})
+
+	assert.Equal(t, Event{Type: EventKey, Key: KeyRune, Ch: 'q'}, screen.PollEvent())
+}
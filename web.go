@@ -0,0 +1,250 @@
+package monitrondashboard
+
+// Web dashboard for the monitron dashboard.
+// Here you'll find code for serving an HTML version of the dashboard
+// grid and pushing build updates to browsers with Server-Sent Events.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// webBuild is a build as sent to browsers, with the colours it is drawn
+// with on the terminal.
+type webBuild struct {
+	jsonOutputBuild
+	Background string `json:"background"`
+	Foreground string `json:"foreground"`
+}
+
+// webBuildUpdate is the structure sent to browsers for each BuildUpdate.
+type webBuildUpdate struct {
+	Builds []webBuild       `json:"builds"`
+	Error  *jsonOutputError `json:"error"`
+}
+
+// WebServer is a BuildFetcher that wraps another BuildFetcher, passing on
+// every BuildUpdate and also pushing it to any browsers viewing the web
+// dashboard. It is an http.Handler serving the dashboard page on / and
+// the event stream on /events.
+type WebServer struct {
+	fetcher      BuildFetcher
+	buildChannel chan BuildUpdate
+	theme        Theme
+
+	mutex   sync.Mutex
+	latest  []byte
+	clients map[chan []byte]bool
+}
+
+// NewWebServer creates a WebServer serving the updates from fetcher,
+// with builds coloured by theme.
+func NewWebServer(fetcher BuildFetcher, theme Theme) *WebServer {
+	server := &WebServer{
+		fetcher:      fetcher,
+		buildChannel: make(chan BuildUpdate),
+		theme:        theme,
+		clients:      map[chan []byte]bool{},
+	}
+	go server.forwardBuilds()
+	return server
+}
+
+func (s *WebServer) BuildChannel() chan BuildUpdate {
+	return s.buildChannel
+}
+
+// forwardBuilds sends every update from the wrapped fetcher to the
+// browsers and then passes it on.
+func (s *WebServer) forwardBuilds() {
+	for buildUpdate := range s.fetcher.BuildChannel() {
+		s.broadcast(buildUpdate)
+		s.buildChannel <- buildUpdate
+	}
+	close(s.buildChannel)
+}
+
+// broadcast sends buildUpdate to every connected browser. Each update is
+// a full snapshot so a browser that hasn't read the last one yet only
+// needs the newest.
+func (s *WebServer) broadcast(buildUpdate BuildUpdate) {
+	event, err := json.Marshal(newWebBuildUpdate(buildUpdate, s.theme))
+	if err != nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.latest = event
+	for client := range s.clients {
+		select {
+		case <-client:
+		default:
+		}
+		client <- event
+	}
+}
+
+// newWebBuildUpdate converts a BuildUpdate into the form sent to browsers,
+// using the colours of the builds' states in theme.
+func newWebBuildUpdate(buildUpdate BuildUpdate, theme Theme) webBuildUpdate {
+	jsonUpdate := newJSONBuildUpdate(buildUpdate)
+	update := webBuildUpdate{
+		Builds: make([]webBuild, 0, len(buildUpdate.builds)),
+		Error:  jsonUpdate.Error,
+	}
+	for i, build := range buildUpdate.builds {
+		update.Builds = append(update.Builds, webBuild{
+			jsonOutputBuild: jsonUpdate.Builds[i],
+			Background:      colourToHex(theme.stateStyle(build.buildState).Bg),
+			Foreground:      colourToHex(theme.stateStyle(build.buildState).Fg),
+		})
+	}
+	return update
+}
+
+func (s *WebServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, webDashboardPage)
+	case "/events":
+		s.serveEvents(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveEvents streams build updates to a browser as Server-Sent Events,
+// starting with the latest update so new viewers don't wait for a change.
+func (s *WebServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	client := make(chan []byte, 1)
+	s.mutex.Lock()
+	if s.latest != nil {
+		client <- s.latest
+	}
+	s.clients[client] = true
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.clients, client)
+		s.mutex.Unlock()
+	}()
+
+	flusher.Flush()
+	for {
+		select {
+		case event := <-client:
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// colourToHex returns the hex colour a colour is drawn as, the default
+// colour is drawn as black.
+func colourToHex(colour Colour) string {
+	r, g, b := colour.rgb()
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// webDashboardPage is the HTML page for the web dashboard. Builds are
+// laid out in columns, top to bottom, like the terminal grid.
+const webDashboardPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>MONITRON 5000</title>
+<style>
+  html, body { margin: 0; height: 100%; background: #000; color: #fff;
+    font-family: monospace; }
+  h1 { margin: 0; padding: 0.5em; text-align: center; font-size: 1.2em; }
+  #error { padding: 0 1em; }
+  #builds { display: grid; grid-auto-flow: column; gap: 8px; padding: 8px;
+    box-sizing: border-box; height: calc(100% - 3em); }
+  .build { display: flex; align-items: center; border: 2px solid #fff;
+    padding: 0 0.5em; overflow: hidden; min-width: 0; }
+  .escalated { animation: flash 1s steps(2, jump-none) infinite; }
+  @keyframes flash { from { border-color: #fff; } to { border-color: #f00; } }
+  .swatch { flex: none; width: 3em; height: 2.5em; margin-right: 1em; }
+  .text { overflow: hidden; white-space: nowrap; text-overflow: ellipsis; }
+</style>
+</head>
+<body>
+<h1>MONITRON 5000</h1>
+<div id="error"></div>
+<div id="builds"></div>
+<script>
+var boxHeight = 80;
+var latest = null;
+
+function text(tag, className, content) {
+  var element = document.createElement(tag);
+  element.className = className;
+  element.textContent = content;
+  return element;
+}
+
+function render(update) {
+  latest = update;
+  var errorElement = document.getElementById("error");
+  var buildsElement = document.getElementById("builds");
+  errorElement.textContent = update.error ? "Error: " + update.error.message : "";
+  buildsElement.innerHTML = "";
+
+  var rows = Math.max(1, Math.floor(buildsElement.clientHeight / boxHeight));
+  rows = Math.min(rows, Math.max(1, update.builds.length));
+  buildsElement.style.gridTemplateRows = "repeat(" + rows + ", 1fr)";
+
+  var builds = update.builds.filter(function(build) { return build.escalated; })
+    .concat(update.builds.filter(function(build) { return !build.escalated; }));
+  builds.forEach(function(build) {
+    var box = document.createElement("div");
+    box.className = build.escalated ? "build escalated" : "build";
+    var swatch = text("div", "swatch", "");
+    swatch.style.background = build.background;
+    box.appendChild(swatch);
+
+    var details = document.createElement("div");
+    details.className = "text";
+    details.appendChild(text("div", "text", build.name));
+    var status = build.building ? "Building " : "";
+    details.appendChild(text("div", "text", status + build.acknowledger));
+    box.appendChild(details);
+    buildsElement.appendChild(box);
+  });
+}
+
+window.addEventListener("resize", function() {
+  if (latest) {
+    render(latest);
+  }
+});
+
+var events = new EventSource("events");
+events.onmessage = function(event) {
+  render(JSON.parse(event.data));
+};
+events.onerror = function() {
+  document.getElementById("error").textContent =
+    "Error: Lost connection to monidash";
+};
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,72 @@
+package monitrondashboard
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var colourToHexTests = []struct {
+	in  Colour
+	out string
+}{
+	{ColourRed, "#800000"},
+	{ColourGreen, "#008000"},
+	{ColourWhite, "#c0c0c0"},
+	{xtermColour(166), "#d75f00"},
+	{xtermColour(166) | AttrBold, "#d75f00"},
+	{Colour(245), "#808080"},
+}
+
+func TestAttributeToHex(t *testing.T) {
+	for _, test := range colourToHexTests {
+		assert.Equal(t, test.out, colourToHex(test.in),
+			"colourToHex(%d)", test.in)
+	}
+}
+
+func TestWebServerServesTheDashboardPage(t *testing.T) {
+	server := NewWebServer(stubBuildFetcher{make(chan BuildUpdate)}, DefaultTheme)
+	recorder := httptest.NewRecorder()
+
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "MONITRON 5000")
+}
+
+func TestWebServerStreamsTheLatestUpdateToNewBrowsers(t *testing.T) {
+	fetcher := stubBuildFetcher{make(chan BuildUpdate, 1)}
+	server := NewWebServer(fetcher, DefaultTheme)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	fetcher.buildChannel <- BuildUpdate{builds: []build{
+		{name: "Build", buildState: BuildStateFailed},
+	}}
+	<-server.BuildChannel()
+
+	response, err := http.Get(httpServer.URL + "/events")
+	if err != nil {
+		t.Fatalf("Unexpected error requesting events: %s", err)
+	}
+	defer response.Body.Close()
+
+	line, err := bufio.NewReader(response.Body).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+	assert.Equal(t, `data: {"builds":[{"name":"Build","state":"failed",`+
+		`"building":false,"acknowledger":"","escalated":false,"background":"#800000",`+
+		`"foreground":"#c0c0c0"}],"error":null}`+"\n", line)
+}
+
+func TestWebBuildsAreColouredByTheTheme(t *testing.T) {
+	update := newWebBuildUpdate(BuildUpdate{builds: []build{
+		{name: "Build", buildState: BuildStateFailed},
+	}}, BuiltinThemes["high-contrast"])
+
+	assert.Equal(t, "#ff0000", update.Builds[0].Background)
+	assert.Equal(t, "#ffffff", update.Builds[0].Foreground)
+}
@@ -0,0 +1,227 @@
+package monitrondashboard
+
+// Webhook notifications for the monitron dashboard.
+// Here you'll find code for POSTing a message to webhooks whenever a
+// build changes state, waiting for flapping builds to settle first and
+// retrying deliveries that fail.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// webhookRetries is how many times a failed delivery is retried.
+	webhookRetries = 5
+	// webhookBackoff is how long to wait before the first retry, each
+	// retry waits twice as long as the last.
+	webhookBackoff = time.Second
+)
+
+// webhookFormats are the payload formats webhooks can be sent in.
+var webhookFormats = []string{"json", "slack", "mattermost", "teams"}
+
+// Webhook is a URL to POST build state changes to, in one of the
+// webhookFormats.
+type Webhook struct {
+	URL    string
+	Format string
+}
+
+// ParseWebhook parses a webhook given as a URL, which is sent JSON, or
+// as a format and URL separated by an equals sign, e.g.
+// slack=https://hooks.slack.com/services/...
+func ParseWebhook(webhook string) (Webhook, error) {
+	if i := strings.Index(webhook, "="); i > 0 && !strings.Contains(webhook[:i], "/") {
+		format := webhook[:i]
+		for _, knownFormat := range webhookFormats {
+			if format == knownFormat {
+				return Webhook{URL: webhook[i+1:], Format: format}, nil
+			}
+		}
+		return Webhook{}, fmt.Errorf("Unknown webhook format: %s", format)
+	}
+	return Webhook{URL: webhook, Format: "json"}, nil
+}
+
+// payload returns the body to POST to the webhook for change, coloured
+// by theme in formats that have colours.
+func (w Webhook) payload(change buildChange, theme Theme) ([]byte, error) {
+	message := describeBuildChange(change)
+	switch w.Format {
+	case "slack", "mattermost":
+		return json.Marshal(map[string]string{"text": message})
+	case "teams":
+		return json.Marshal(map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "http://schema.org/extensions",
+			"themeColor": strings.TrimPrefix(colourToHex(theme.stateStyle(change.current.buildState).Bg), "#"),
+			"summary":    message,
+			"text":       message,
+		})
+	}
+	return json.Marshal(struct {
+		jsonOutputBuild
+		PreviousState string `json:"previous_state"`
+		Message       string `json:"message"`
+	}{
+		newJSONOutputBuild(change.current),
+		change.previous.buildState.String(),
+		message,
+	})
+}
+
+// pendingChange is a change to a build waiting for the build to settle.
+type pendingChange struct {
+	change buildChange
+	timer  Timer
+}
+
+// webhookSender sends state changes to webhooks. A change is only sent
+// once its build has stayed the same for the debounce period, so a build
+// flapping between states sends a single message, or none if it ends up
+// back where it started.
+type webhookSender struct {
+	webhooks []Webhook
+	debounce time.Duration
+	theme    Theme
+	client   *http.Client
+	retries  int
+	backoff  time.Duration
+	clock    Clock
+
+	mutex      sync.Mutex
+	pending    map[string]*pendingChange
+	delivering sync.WaitGroup
+}
+
+// newWebhookSender creates a webhookSender for webhooks, colouring
+// messages with theme and timing the debounce period and retries with
+// clock.
+func newWebhookSender(webhooks []Webhook, debounce time.Duration, theme Theme,
+	clock Clock) *webhookSender {
+	return &webhookSender{
+		webhooks: webhooks,
+		debounce: debounce,
+		theme:    theme,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		retries:  webhookRetries,
+		backoff:  webhookBackoff,
+		clock:    clock,
+		pending:  map[string]*pendingChange{},
+	}
+}
+
+// change records a change to a build, sending it once the build settles.
+func (s *webhookSender) change(change buildChange) {
+	if change.kind != buildChanged {
+		return
+	}
+	name := change.name()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if pending, ok := s.pending[name]; ok && pending.timer.Stop() {
+		pending.change.current = change.current
+		s.settleAfterDebounce(name, pending)
+		return
+	}
+
+	// There's either nothing pending for the build or its timer has
+	// already fired and will send what it has.
+	pending := &pendingChange{change: change}
+	s.delivering.Add(1)
+	s.settleAfterDebounce(name, pending)
+	s.pending[name] = pending
+}
+
+// settleAfterDebounce starts the timer settling pending once the build
+// called name has stayed the same for the debounce period, it must be
+// called with the mutex held.
+func (s *webhookSender) settleAfterDebounce(name string, pending *pendingChange) {
+	pending.timer = s.clock.AfterFunc(s.debounce, func() {
+		defer s.delivering.Done()
+		s.settle(name, pending)
+	})
+}
+
+// settle sends a pending change for the build called name.
+func (s *webhookSender) settle(name string, pending *pendingChange) {
+	s.mutex.Lock()
+	if s.pending[name] == pending {
+		delete(s.pending, name)
+	}
+	change := pending.change
+	s.mutex.Unlock()
+
+	s.send(change)
+}
+
+// close sends all pending changes straight away and waits for every
+// delivery to finish.
+func (s *webhookSender) close() {
+	s.mutex.Lock()
+	settled := map[string]*pendingChange{}
+	for name, pending := range s.pending {
+		if pending.timer.Stop() {
+			settled[name] = pending
+		}
+	}
+	s.mutex.Unlock()
+
+	for name, pending := range settled {
+		s.settle(name, pending)
+		s.delivering.Done()
+	}
+	s.delivering.Wait()
+}
+
+// send delivers change to every webhook if the build's state changed.
+func (s *webhookSender) send(change buildChange) {
+	if change.previous.buildState == change.current.buildState {
+		return
+	}
+	for _, webhook := range s.webhooks {
+		if err := s.deliver(webhook, change); err != nil {
+			log.Printf("Error sending webhook to %s: %s", webhook.URL, err)
+		}
+	}
+}
+
+// deliver POSTs change to webhook, retrying with an exponential backoff.
+func (s *webhookSender) deliver(webhook Webhook, change buildChange) error {
+	payload, err := webhook.payload(change, s.theme)
+	if err != nil {
+		return err
+	}
+
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		err = s.post(webhook.URL, payload)
+		if err == nil || attempt == s.retries {
+			return err
+		}
+		<-s.clock.After(backoff)
+		backoff *= 2
+	}
+}
+
+// post POSTs payload to url, returning an error for any response that
+// isn't a success.
+func (s *webhookSender) post(url string, payload []byte) error {
+	response, err := s.client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("Unexpected response: %s", response.Status)
+	}
+	return nil
+}
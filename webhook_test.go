@@ -0,0 +1,177 @@
+package monitrondashboard
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var parseWebhookTests = []struct {
+	in          string
+	out         Webhook
+	shouldError bool
+}{
+	{"http://localhost/hook?a=b", Webhook{"http://localhost/hook?a=b", "json"}, false},
+	{"slack=https://hooks.slack.com/x", Webhook{"https://hooks.slack.com/x", "slack"}, false},
+	{"teams=https://outlook.office.com/x", Webhook{"https://outlook.office.com/x", "teams"}, false},
+	{"irc=https://localhost/x", Webhook{}, true},
+}
+
+func TestParseWebhook(t *testing.T) {
+	for _, test := range parseWebhookTests {
+		out, err := ParseWebhook(test.in)
+		if test.shouldError {
+			assert.Error(t, err, "ParseWebhook(%q) should fail", test.in)
+		} else {
+			assert.NoError(t, err, "ParseWebhook(%q) should not fail", test.in)
+		}
+		assert.Equal(t, test.out, out)
+	}
+}
+
+// webhookReceiver is a local webhook that records the payloads it is
+// sent, failing the first failures requests.
+type webhookReceiver struct {
+	*httptest.Server
+	payloads chan map[string]interface{}
+	failures int
+}
+
+func newWebhookReceiver(failures int) *webhookReceiver {
+	receiver := &webhookReceiver{
+		payloads: make(chan map[string]interface{}, 10),
+		failures: failures,
+	}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if receiver.failures > 0 {
+				receiver.failures--
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			payload := map[string]interface{}{}
+			json.Unmarshal(body, &payload)
+			receiver.payloads <- payload
+		}))
+	return receiver
+}
+
+// webhookTestChange creates a change to the payments-api build.
+func webhookTestChange(from, to buildState) buildChange {
+	return buildChange{
+		buildChanged,
+		build{name: "payments-api", buildState: from},
+		build{name: "payments-api", buildState: to},
+	}
+}
+
+func TestWebhookSenderPostsStateChanges(t *testing.T) {
+	receiver := newWebhookReceiver(0)
+	defer receiver.Close()
+	sender := newWebhookSender([]Webhook{{receiver.URL, "json"}}, time.Millisecond,
+		DefaultTheme, SystemClock)
+
+	sender.change(webhookTestChange(BuildStatePassed, BuildStateFailed))
+	sender.close()
+
+	payload := <-receiver.payloads
+	assert.Equal(t, "payments-api", payload["name"])
+	assert.Equal(t, "failed", payload["state"])
+	assert.Equal(t, "passed", payload["previous_state"])
+	assert.Equal(t, "payments-api: passed → FAILED", payload["message"])
+}
+
+func TestWebhookSenderSendsSlackMessages(t *testing.T) {
+	receiver := newWebhookReceiver(0)
+	defer receiver.Close()
+	sender := newWebhookSender([]Webhook{{receiver.URL, "slack"}}, time.Millisecond,
+		DefaultTheme, SystemClock)
+
+	sender.change(webhookTestChange(BuildStateFailed, BuildStatePassed))
+	sender.close()
+
+	assert.Equal(t, map[string]interface{}{"text": "payments-api: FAILED → passed"},
+		<-receiver.payloads)
+}
+
+func TestTeamsMessagesAreColouredByTheTheme(t *testing.T) {
+	payload, err := Webhook{"https://outlook.office.com/x", "teams"}.payload(
+		webhookTestChange(BuildStatePassed, BuildStateFailed), BuiltinThemes["high-contrast"])
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(payload), `"themeColor":"ff0000"`)
+}
+
+func TestWebhookSenderDebouncesFlappingBuilds(t *testing.T) {
+	receiver := newWebhookReceiver(0)
+	defer receiver.Close()
+	sender := newWebhookSender([]Webhook{{receiver.URL, "json"}}, time.Hour,
+		DefaultTheme, SystemClock)
+
+	sender.change(webhookTestChange(BuildStatePassed, BuildStateFailed))
+	sender.change(webhookTestChange(BuildStateFailed, BuildStatePassed))
+	sender.change(webhookTestChange(BuildStatePassed, BuildStateFailed))
+	sender.change(webhookTestChange(BuildStateFailed, BuildStateAcknowledged))
+	sender.close()
+
+	payload := <-receiver.payloads
+	assert.Equal(t, "passed", payload["previous_state"])
+	assert.Equal(t, "acknowledged", payload["state"])
+	assert.Empty(t, receiver.payloads, "A flapping build should only be sent once")
+}
+
+func TestWebhookSenderWaitsForTheBuildToSettle(t *testing.T) {
+	receiver := newWebhookReceiver(0)
+	defer receiver.Close()
+	clock := NewFakeClock(time.Unix(1425590828, 0))
+	sender := newWebhookSender([]Webhook{{receiver.URL, "json"}}, time.Minute,
+		DefaultTheme, clock)
+
+	sender.change(webhookTestChange(BuildStatePassed, BuildStateFailed))
+	clock.Advance(50 * time.Second)
+	sender.change(webhookTestChange(BuildStateFailed, BuildStateAcknowledged))
+	clock.Advance(50 * time.Second)
+	select {
+	case <-receiver.payloads:
+		t.Fatalf("The change should wait a minute after the build last changed")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.Advance(10 * time.Second)
+	payload := <-receiver.payloads
+	assert.Equal(t, "passed", payload["previous_state"])
+	assert.Equal(t, "acknowledged", payload["state"])
+	sender.close()
+}
+
+func TestWebhookSenderIgnoresBuildsThatFlapBack(t *testing.T) {
+	receiver := newWebhookReceiver(0)
+	defer receiver.Close()
+	sender := newWebhookSender([]Webhook{{receiver.URL, "json"}}, time.Hour,
+		DefaultTheme, SystemClock)
+
+	sender.change(webhookTestChange(BuildStatePassed, BuildStateFailed))
+	sender.change(webhookTestChange(BuildStateFailed, BuildStatePassed))
+	sender.close()
+
+	assert.Empty(t, receiver.payloads)
+}
+
+func TestWebhookSenderRetriesFailedDeliveries(t *testing.T) {
+	receiver := newWebhookReceiver(2)
+	defer receiver.Close()
+	sender := newWebhookSender([]Webhook{{receiver.URL, "json"}}, time.Millisecond,
+		DefaultTheme, SystemClock)
+	sender.backoff = time.Millisecond
+
+	sender.change(webhookTestChange(BuildStatePassed, BuildStateFailed))
+	sender.close()
+
+	payload := <-receiver.payloads
+	assert.Equal(t, "failed", payload["state"])
+}
@@ -0,0 +1,451 @@
+package monitrondashboard
+
+// Widgets for the monitron dashboard.
+// Here you'll find the widgets the dashboard's panels are composed of,
+// from labels to rows, columns and grids of other widgets, and the Canvas
+// they draw on with RuneWriters and AttributeWriters.
+
+// Canvas is an off screen area of cells that widgets draw on, applying
+// RuneWriters and AttributeWriters to the cells they cover, before it is
+// drawn on a CellDrawer.
+type Canvas struct {
+	bounds rect
+	cells  []Cell
+}
+
+// NewCanvas creates a Canvas covering bounds, blank in style.
+func NewCanvas(bounds rect, style Style) *Canvas {
+	if bounds.w < 0 || bounds.h < 0 {
+		bounds.size = size{}
+	}
+	canvas := &Canvas{bounds: bounds, cells: make([]Cell, bounds.w*bounds.h)}
+	for i := range canvas.cells {
+		canvas.cells[i] = Cell{Ch: ' ', Fg: style.Fg, Bg: style.Bg}
+	}
+	return canvas
+}
+
+// WriteRunes changes the characters of the cells within area to those
+// returned by writer.
+func (c *Canvas) WriteRunes(area rect, writer RuneWriter) {
+	c.each(area, func(cell *Cell, point point) {
+		cell.Ch = writer(cell.Ch, point)
+	})
+}
+
+// WriteAttributes changes the colours of the cells within area to those
+// returned by writer.
+func (c *Canvas) WriteAttributes(area rect, writer AttributeWriter) {
+	c.each(area, func(cell *Cell, point point) {
+		cell.Fg, cell.Bg = writer(cell.Fg, cell.Bg, point)
+	})
+}
+
+// DrawOn draws every cell of the canvas on drawer.
+func (c *Canvas) DrawOn(drawer CellDrawer) {
+	for i, cell := range c.cells {
+		drawer.SetCell(c.bounds.x+i%c.bounds.w, c.bounds.y+i/c.bounds.w,
+			cell.Ch, cell.Fg, cell.Bg)
+	}
+}
+
+// each calls f with every cell within area that is on the canvas.
+func (c *Canvas) each(area rect, f func(cell *Cell, point point)) {
+	left, top := maxInt(area.x, c.bounds.x), maxInt(area.y, c.bounds.y)
+	right := minInt(area.x+area.w, c.bounds.x+c.bounds.w)
+	bottom := minInt(area.y+area.h, c.bounds.y+c.bounds.h)
+	for y := top; y < bottom; y++ {
+		for x := left; x < right; x++ {
+			f(&c.cells[(y-c.bounds.y)*c.bounds.w+x-c.bounds.x], point{x, y})
+		}
+	}
+}
+
+// Widget is a part of the screen that can be composed with others. It is
+// measured, laid out within bounds and then drawn where it was laid out.
+type Widget interface {
+	// Measure returns the smallest size the widget can be drawn at.
+	Measure() size
+	// Layout places the widget and its children within bounds, returning
+	// an error if they don't fit.
+	Layout(bounds rect) error
+	// Draw draws the widget on canvas.
+	Draw(canvas *Canvas)
+}
+
+// Alignment is where a Label's text is placed within its width.
+type Alignment int
+
+const (
+	AlignLeft Alignment = iota
+	AlignCentre
+	AlignRight
+)
+
+// Label is a Widget drawing a line of text, ellipsized if it doesn't fit.
+type Label struct {
+	text   string
+	style  Style
+	align  Alignment
+	bounds rect
+}
+
+// NewLabel creates a Label drawing text in style, placed within its
+// width by align.
+func NewLabel(text string, style Style, align Alignment) *Label {
+	return &Label{text: text, style: style, align: align}
+}
+
+func (l *Label) Measure() size {
+	return size{len([]rune(l.text)), 1}
+}
+
+func (l *Label) Layout(bounds rect) error {
+	l.bounds = bounds
+	return nil
+}
+
+func (l *Label) Draw(canvas *Canvas) {
+	if l.bounds.h < 1 {
+		return
+	}
+	text := fitText(l.text, l.bounds.w)
+	length := len([]rune(text))
+	x := l.bounds.x
+	switch l.align {
+	case AlignCentre:
+		x += (l.bounds.w - length) / 2
+	case AlignRight:
+		x += l.bounds.w - length
+	}
+	area := NewRect(x, l.bounds.y, length, 1)
+	canvas.WriteRunes(area, createTextWriter(text, area.point))
+	canvas.WriteAttributes(area, createStyleWriter(area, l.style))
+}
+
+// fitText returns text ellipsized to width, or cut short if width is too
+// narrow for an ellipsis.
+func fitText(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if fitted, err := elipsize(text, width); err == nil {
+		return fitted
+	}
+	runes := []rune(text)
+	if len(runes) > width {
+		runes = runes[:width]
+	}
+	return string(runes)
+}
+
+// Spacer is a Widget that draws nothing, taking up space between others.
+type Spacer struct {
+	size size
+}
+
+// NewSpacer creates a Spacer measuring width by height.
+func NewSpacer(width, height int) *Spacer {
+	return &Spacer{size{width, height}}
+}
+
+func (s *Spacer) Measure() size {
+	return s.size
+}
+
+func (s *Spacer) Layout(bounds rect) error {
+	return nil
+}
+
+func (s *Spacer) Draw(canvas *Canvas) {
+}
+
+// expanded wraps a Widget that shares out the space left over in Rows or
+// Columns.
+type expanded struct {
+	Widget
+}
+
+// Expand marks widget to be given a share of the space left over when it
+// is laid out in Rows or Columns, rather than only its measured size.
+func Expand(widget Widget) Widget {
+	return expanded{widget}
+}
+
+// Rows is a Widget laying out its children from top to bottom, each the
+// full width of the rows.
+type Rows struct {
+	children []Widget
+}
+
+// NewRows creates Rows of children.
+func NewRows(children ...Widget) *Rows {
+	return &Rows{children}
+}
+
+func (r *Rows) Measure() size {
+	measured := size{}
+	for _, child := range r.children {
+		childSize := child.Measure()
+		measured.w = maxInt(measured.w, childSize.w)
+		measured.h += childSize.h
+	}
+	return measured
+}
+
+func (r *Rows) Layout(bounds rect) error {
+	heights := shareSpace(r.children, bounds.h, func(s size) int { return s.h })
+	y := bounds.y
+	for i, child := range r.children {
+		if err := child.Layout(NewRect(bounds.x, y, bounds.w, heights[i])); err != nil {
+			return err
+		}
+		y += heights[i]
+	}
+	return nil
+}
+
+func (r *Rows) Draw(canvas *Canvas) {
+	for _, child := range r.children {
+		child.Draw(canvas)
+	}
+}
+
+// Columns is a Widget laying out its children from left to right, each
+// the full height of the columns.
+type Columns struct {
+	children []Widget
+}
+
+// NewColumns creates Columns of children.
+func NewColumns(children ...Widget) *Columns {
+	return &Columns{children}
+}
+
+func (c *Columns) Measure() size {
+	measured := size{}
+	for _, child := range c.children {
+		childSize := child.Measure()
+		measured.w += childSize.w
+		measured.h = maxInt(measured.h, childSize.h)
+	}
+	return measured
+}
+
+func (c *Columns) Layout(bounds rect) error {
+	widths := shareSpace(c.children, bounds.w, func(s size) int { return s.w })
+	x := bounds.x
+	for i, child := range c.children {
+		if err := child.Layout(NewRect(x, bounds.y, widths[i], bounds.h)); err != nil {
+			return err
+		}
+		x += widths[i]
+	}
+	return nil
+}
+
+func (c *Columns) Draw(canvas *Canvas) {
+	for _, child := range c.children {
+		child.Draw(canvas)
+	}
+}
+
+// shareSpace shares available space between children along the
+// dimension returned by length. Each child gets its measured length, with
+// anything left over shared between expanded children, and if there
+// isn't enough space the last children get less, or none.
+func shareSpace(children []Widget, available int, length func(size) int) []int {
+	lengths := make([]int, len(children))
+	expandedChildren := 0
+	remaining := available
+	for i, child := range children {
+		lengths[i] = minInt(length(child.Measure()), maxInt(remaining, 0))
+		remaining -= lengths[i]
+		if _, ok := child.(expanded); ok {
+			expandedChildren++
+		}
+	}
+	for i, child := range children {
+		if _, ok := child.(expanded); ok && remaining > 0 {
+			share := remaining / expandedChildren
+			lengths[i] += share
+			remaining -= share
+			expandedChildren--
+		}
+	}
+	return lengths
+}
+
+// Stack is a Widget laying out its children on top of each other, drawn
+// in order.
+type Stack struct {
+	children []Widget
+}
+
+// NewStack creates a Stack of children.
+func NewStack(children ...Widget) *Stack {
+	return &Stack{children}
+}
+
+func (s *Stack) Measure() size {
+	measured := size{}
+	for _, child := range s.children {
+		childSize := child.Measure()
+		measured.w = maxInt(measured.w, childSize.w)
+		measured.h = maxInt(measured.h, childSize.h)
+	}
+	return measured
+}
+
+func (s *Stack) Layout(bounds rect) error {
+	for _, child := range s.children {
+		if err := child.Layout(bounds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Stack) Draw(canvas *Canvas) {
+	for _, child := range s.children {
+		child.Draw(canvas)
+	}
+}
+
+// Padding is a Widget leaving space around its child.
+type Padding struct {
+	child                    Widget
+	top, right, bottom, left int
+}
+
+// NewPadding creates Padding around child, with the space on each side
+// given in the order top, right, bottom, left.
+func NewPadding(top, right, bottom, left int, child Widget) *Padding {
+	return &Padding{child, top, right, bottom, left}
+}
+
+func (p *Padding) Measure() size {
+	measured := p.child.Measure()
+	return size{measured.w + p.left + p.right, measured.h + p.top + p.bottom}
+}
+
+func (p *Padding) Layout(bounds rect) error {
+	return p.child.Layout(NewRect(bounds.x+p.left, bounds.y+p.top,
+		maxInt(bounds.w-p.left-p.right, 0), maxInt(bounds.h-p.top-p.bottom, 0)))
+}
+
+func (p *Padding) Draw(canvas *Canvas) {
+	p.child.Draw(canvas)
+}
+
+// Border is a Widget drawing a box around its child.
+type Border struct {
+	child  Widget
+	colour Colour
+	bounds rect
+}
+
+// NewBorder creates a Border drawn in colour around child.
+func NewBorder(colour Colour, child Widget) *Border {
+	return &Border{child: child, colour: colour}
+}
+
+func (b *Border) Measure() size {
+	measured := b.child.Measure()
+	return size{measured.w + 2, measured.h + 2}
+}
+
+func (b *Border) Layout(bounds rect) error {
+	b.bounds = bounds
+	return b.child.Layout(NewRect(bounds.x+1, bounds.y+1,
+		maxInt(bounds.w-2, 0), maxInt(bounds.h-2, 0)))
+}
+
+func (b *Border) Draw(canvas *Canvas) {
+	canvas.WriteRunes(b.bounds, createBorderedBoxWriter(b.bounds))
+	canvas.WriteAttributes(b.bounds, createBorderColourWriter(b.bounds, b.colour))
+	b.child.Draw(canvas)
+}
+
+// Background is a Widget filling the background behind its child with a
+// colour.
+type Background struct {
+	child  Widget
+	colour Colour
+	bounds rect
+}
+
+// NewBackground creates a Background of colour behind child.
+func NewBackground(colour Colour, child Widget) *Background {
+	return &Background{child: child, colour: colour}
+}
+
+func (b *Background) Measure() size {
+	return b.child.Measure()
+}
+
+func (b *Background) Layout(bounds rect) error {
+	b.bounds = bounds
+	return b.child.Layout(bounds)
+}
+
+func (b *Background) Draw(canvas *Canvas) {
+	canvas.WriteAttributes(b.bounds, createBoxFillWriter(b.bounds, b.colour))
+	b.child.Draw(canvas)
+}
+
+// Grid is a Widget laying out its children in a grid of boxes at least
+// boxSize, as many columns across as will fit.
+type Grid struct {
+	children []Widget
+	boxSize  size
+	padding  int
+}
+
+// NewGrid creates a Grid of children, each at least boxSize with padding
+// between them.
+func NewGrid(boxSize size, padding int, children ...Widget) *Grid {
+	return &Grid{children, boxSize, padding}
+}
+
+func (g *Grid) Measure() size {
+	if len(g.children) == 0 {
+		return size{}
+	}
+	return size{g.boxSize.w + 2*g.padding, g.boxSize.h + 2*g.padding}
+}
+
+// Layout places the children in the grid, returning an error if bounds
+// is too small to fit them all.
+func (g *Grid) Layout(bounds rect) error {
+	layout, err := layoutGridForScreen(g.boxSize, len(g.children), g.padding, bounds)
+	if err != nil {
+		return err
+	}
+	for i, child := range g.children {
+		if err := child.Layout(layout.boxes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Grid) Draw(canvas *Canvas) {
+	for _, child := range g.children {
+		child.Draw(canvas)
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
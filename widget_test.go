@@ -0,0 +1,131 @@
+package monitrondashboard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// drawWidgetText lays out and draws widget on a width by height screen,
+// returning the text drawn.
+func drawWidgetText(t *testing.T, widget Widget, width, height int) string {
+	screen := NewVirtualScreen(width, height)
+	bounds := NewRect(0, 0, width, height)
+	assert.Nil(t, widget.Layout(bounds))
+	canvas := NewCanvas(bounds, DefaultTheme.Text)
+	widget.Draw(canvas)
+	canvas.DrawOn(screen)
+	return strings.TrimRight(screen.Text(), "\n")
+}
+
+var labelTests = []struct {
+	text  string
+	align Alignment
+	width int
+	out   string
+}{
+	{"label", AlignLeft, 9, "label"},
+	{"label", AlignCentre, 9, "  label"},
+	{"label", AlignRight, 9, "    label"},
+	{"a long label", AlignLeft, 9, "a long..."},
+	{"a long label", AlignRight, 9, "a long..."},
+	{"label", AlignLeft, 2, "la"},
+}
+
+func TestLabelAlignsAndClipsItsText(t *testing.T) {
+	for _, test := range labelTests {
+		label := NewLabel(test.text, DefaultTheme.Text, test.align)
+		assert.Equal(t, test.out, drawWidgetText(t, label, test.width, 1),
+			"%q aligned %d in %d", test.text, test.align, test.width)
+	}
+}
+
+func TestLabelIsDrawnInItsStyle(t *testing.T) {
+	screen := NewVirtualScreen(10, 1)
+	label := NewLabel("ab", DefaultTheme.Error, AlignLeft)
+	bounds := NewRect(0, 0, 10, 1)
+	label.Layout(bounds)
+	canvas := NewCanvas(bounds, DefaultTheme.Text)
+	label.Draw(canvas)
+	canvas.DrawOn(screen)
+
+	assertCellColours(t, screen, 1, 0, DefaultTheme.Error.Fg, DefaultTheme.Error.Bg,
+		"the error colour", "the error background")
+	assertCellColours(t, screen, 2, 0, DefaultTheme.Text.Fg, DefaultTheme.Text.Bg,
+		"the text colour", "the text background")
+}
+
+func TestRowsAndColumnsShareSpaceBetweenExpandedChildren(t *testing.T) {
+	widget := NewRows(
+		NewColumns(
+			NewLabel("a", DefaultTheme.Text, AlignLeft),
+			Expand(NewLabel("b", DefaultTheme.Text, AlignCentre)),
+			NewLabel("c", DefaultTheme.Text, AlignLeft),
+		),
+		Expand(NewSpacer(0, 0)),
+		NewLabel("d", DefaultTheme.Text, AlignRight),
+	)
+
+	assert.Equal(t, size{3, 2}, widget.Measure())
+	assert.Equal(t, "a   b   c\n\n\n        d", drawWidgetText(t, widget, 9, 4))
+}
+
+func TestColumnsClipTheLastChildrenWhenThereIsntRoom(t *testing.T) {
+	widget := NewColumns(
+		NewLabel("first", DefaultTheme.Text, AlignLeft),
+		NewLabel("second", DefaultTheme.Text, AlignLeft),
+		NewLabel("third", DefaultTheme.Text, AlignLeft),
+	)
+
+	assert.Equal(t, "firsts...", drawWidgetText(t, widget, 9, 1))
+}
+
+func TestBorderAndPaddingSurroundTheirChild(t *testing.T) {
+	widget := NewBorder(ColourWhite, NewPadding(0, 1, 0, 2,
+		NewLabel("label", DefaultTheme.Text, AlignLeft)))
+
+	assert.Equal(t, size{10, 3}, widget.Measure())
+	assert.Equal(t, strings.Join([]string{
+		"┏━━━━━━━━━━┓",
+		"┃  label   ┃",
+		"┗━━━━━━━━━━┛",
+	}, "\n"), drawWidgetText(t, widget, 12, 3))
+}
+
+func TestBackgroundFillsBehindItsChild(t *testing.T) {
+	screen := NewVirtualScreen(4, 2)
+	bounds := NewRect(0, 0, 4, 2)
+	widget := NewPadding(0, 0, 0, 1, NewBackground(ColourRed,
+		NewLabel("a", DefaultTheme.Text, AlignLeft)))
+	widget.Layout(bounds)
+	canvas := NewCanvas(bounds, DefaultTheme.Text)
+	widget.Draw(canvas)
+	canvas.DrawOn(screen)
+
+	assertCellColours(t, screen, 0, 0, ColourWhite, ColourBlack,
+		"the text colour", "the text background")
+	assertCellColours(t, screen, 3, 1, ColourWhite, ColourRed,
+		"the text colour", "the background colour")
+}
+
+func TestGridLaysOutItsChildrenInBoxes(t *testing.T) {
+	labels := []Widget{}
+	for _, text := range []string{"a", "b", "c"} {
+		labels = append(labels, NewBorder(ColourWhite,
+			NewLabel(text, DefaultTheme.Text, AlignLeft)))
+	}
+	grid := NewGrid(size{3, 3}, 1, labels...)
+
+	assert.Equal(t, strings.Join([]string{
+		"",
+		" ┏━┓ ┏━┓",
+		" ┃a┃ ┃c┃",
+		" ┗━┛ ┗━┛",
+		"",
+		" ┏━┓",
+		" ┃b┃",
+		" ┗━┛",
+	}, "\n"), drawWidgetText(t, grid, 9, 9))
+	assert.EqualError(t, grid.Layout(NewRect(0, 0, 9, 4)),
+		"Screen is too small to fit the grid")
+}